@@ -2,6 +2,7 @@ package wikilink
 
 import (
 	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
 )
 
 // Kind is the kind of the wikilink AST node.
@@ -42,6 +43,33 @@ type Node struct {
 	//
 	// This indicates that the resource should be embedded (e.g. images).
 	Embed bool
+
+	// Segment is the portion of the source that holds this wikilink,
+	// including the surrounding brackets.
+	//
+	// This is empty for nodes that were not parsed from a source.
+	Segment text.Segment
+
+	// conv is the conversion that this node was parsed in, if any.
+	conv *conversion
+
+	// open records whether the Renderer wrote an opening <a> tag
+	// when entering this node.
+	// This is needed to decide whether a closing </a> must be added
+	// when exiting the node.
+	//
+	// Keeping this on the node rather than the Renderer ensures that
+	// the Renderer holds no per-document state.
+	open bool
+
+	// bare records whether this node was made from plain text
+	// without brackets, like WikiWords and auto-linked mentions,
+	// rather than from a bracketed wikilink.
+	bare bool
+
+	// resolution is the result of resolving this node
+	// with a BatchTransformer, if any.
+	resolution *resolution
 }
 
 var _ ast.Node = (*Node)(nil)
@@ -0,0 +1,95 @@
+package wikilink
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/util"
+)
+
+// Attributer computes additional HTML attributes for rendered wikilinks.
+//
+// Use this to add attributes like class="wikilink", rel="nofollow",
+// or loading="lazy" to the <a> and <img> tags generated by the Renderer.
+type Attributer interface {
+	// WikilinkAttributes returns attributes for the tag
+	// generated for the provided wikilink.
+	// dest is the destination returned by the Resolver.
+	//
+	// Attributes returned by WikilinkAttributes take precedence over
+	// attributes with the same name set on the Node with SetAttribute.
+	//
+	// WikilinkAttributes is not called for wikilinks that do not resolve
+	// to a destination as no tag is generated for them.
+	WikilinkAttributes(n *Node, dest []byte) []ast.Attribute
+}
+
+// attributes returns the attributes of the provided Node
+// merged with the additional attributes produced by the Attributer, if any,
+// and those for the link preview, if any.
+func (r *Renderer) attributes(n *Node, dest []byte, preview *Preview) []ast.Attribute {
+	var extra []ast.Attribute
+	if r.Attributer != nil {
+		extra = r.Attributer.WikilinkAttributes(n, dest)
+	}
+	if preview != nil {
+		for _, attr := range preview.attributes() {
+			if !hasAttribute(extra, attr.Name) {
+				extra = append(extra, attr)
+			}
+		}
+	}
+	if len(extra) == 0 {
+		return n.Attributes()
+	}
+
+	attrs := make([]ast.Attribute, 0, len(n.Attributes())+len(extra))
+	for _, attr := range n.Attributes() {
+		if !hasAttribute(extra, attr.Name) {
+			attrs = append(attrs, attr)
+		}
+	}
+	return append(attrs, extra...)
+}
+
+// renderAttributes writes the provided attributes.
+//
+// As with goldmark's html.RenderAttributes,
+// only attributes accepted by the filter or with a "data-" prefix
+// are rendered.
+func renderAttributes(w util.BufWriter, attrs []ast.Attribute, filter util.BytesFilter) {
+	for _, attr := range attrs {
+		renderAttribute(w, attr, filter)
+	}
+}
+
+var _dataPrefix = []byte("data-")
+
+func renderAttribute(w util.BufWriter, attr ast.Attribute, filter util.BytesFilter) {
+	if !filter.Contains(attr.Name) && !bytes.HasPrefix(attr.Name, _dataPrefix) {
+		return
+	}
+
+	var value []byte
+	switch v := attr.Value.(type) {
+	case []byte:
+		value = v
+	case string:
+		value = util.StringToReadOnlyBytes(v)
+	}
+
+	_ = w.WriteByte(' ')
+	_, _ = w.Write(attr.Name)
+	_, _ = w.WriteString(`="`)
+	_, _ = w.Write(util.EscapeHTML(value))
+	_ = w.WriteByte('"')
+}
+
+func hasAttribute(attrs []ast.Attribute, name []byte) bool {
+	for _, attr := range attrs {
+		if bytes.Equal(attr.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package wikilink
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark/ast"
+)
+
+type attributerFunc func(*Node, []byte) []ast.Attribute
+
+func (f attributerFunc) WikilinkAttributes(n *Node, dest []byte) []ast.Attribute {
+	return f(n, dest)
+}
+
+func TestRenderer_Attributes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc       string
+		give       *Node
+		nodeAttrs  map[string]string
+		attributer Attributer
+		want       string
+	}{
+		{
+			desc:      "node attributes",
+			give:      &Node{Target: []byte("foo")},
+			nodeAttrs: map[string]string{"class": "wikilink"},
+			want:      `<a href="foo.html" class="wikilink">`,
+		},
+		{
+			desc:      "node attributes/escaped",
+			give:      &Node{Target: []byte("foo")},
+			nodeAttrs: map[string]string{"title": `"a" & b`},
+			want:      `<a href="foo.html" title="&quot;a&quot; &amp; b">`,
+		},
+		{
+			desc:      "node attributes/filtered",
+			give:      &Node{Target: []byte("foo")},
+			nodeAttrs: map[string]string{"onclick": "alert(1)"},
+			want:      `<a href="foo.html">`,
+		},
+		{
+			desc:      "node attributes/data",
+			give:      &Node{Target: []byte("foo")},
+			nodeAttrs: map[string]string{"data-target": "foo"},
+			want:      `<a href="foo.html" data-target="foo">`,
+		},
+		{
+			desc: "attributer",
+			give: &Node{Target: []byte("foo")},
+			attributer: attributerFunc(func(n *Node, dest []byte) []ast.Attribute {
+				return []ast.Attribute{
+					{Name: []byte("rel"), Value: []byte("nofollow")},
+					{Name: []byte("data-target"), Value: string(n.Target)},
+					{Name: []byte("data-dest"), Value: dest},
+				}
+			}),
+			want: `<a href="foo.html" rel="nofollow" data-target="foo" data-dest="foo.html">`,
+		},
+		{
+			desc:      "attributer/overrides node",
+			give:      &Node{Target: []byte("foo")},
+			nodeAttrs: map[string]string{"class": "a", "title": "b"},
+			attributer: attributerFunc(func(*Node, []byte) []ast.Attribute {
+				return []ast.Attribute{
+					{Name: []byte("class"), Value: "c"},
+				}
+			}),
+			want: `<a href="foo.html" title="b" class="c">`,
+		},
+		{
+			desc:      "image",
+			give:      &Node{Target: []byte("foo.png"), Embed: true},
+			nodeAttrs: map[string]string{"class": "photo"},
+			attributer: attributerFunc(func(*Node, []byte) []ast.Attribute {
+				return []ast.Attribute{
+					{Name: []byte("loading"), Value: "lazy"},
+					{Name: []byte("decoding"), Value: "async"},
+					{Name: []byte("rel"), Value: "nofollow"}, // not for images
+				}
+			}),
+			want: `<img src="foo.png" class="photo" loading="lazy" decoding="async">`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			// Set attributes in a fixed order.
+			for _, name := range []string{"class", "title", "onclick", "data-target"} {
+				if v, ok := tt.nodeAttrs[name]; ok {
+					tt.give.SetAttributeString(name, v)
+				}
+			}
+
+			var buff bytes.Buffer
+			w := bufio.NewWriter(&buff)
+			r := Renderer{Attributer: tt.attributer}
+			_, err := r.Render(w, nil /* source */, tt.give, true /* entering */)
+			require.NoError(t, err)
+			require.NoError(t, w.Flush())
+
+			assert.Equal(t, tt.want, buff.String())
+		})
+	}
+}
+
+func TestRenderer_AttributesUnresolved(t *testing.T) {
+	t.Parallel()
+
+	r := Renderer{
+		Resolver: resolverFunc(noopResolver),
+		Attributer: attributerFunc(func(*Node, []byte) []ast.Attribute {
+			t.Errorf("attributer must not be called")
+			return nil
+		}),
+	}
+
+	var buff bytes.Buffer
+	w := bufio.NewWriter(&buff)
+	_, err := r.Render(w, nil /* source */, &Node{Target: []byte("foo")}, true /* entering */)
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	assert.Empty(t, buff.String())
+}
@@ -0,0 +1,351 @@
+package wikilink
+
+import (
+	"sort"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// AutoLinkTransformer is a goldmark AST transformer that turns mentions
+// of known pages in plain text into wikilinks.
+// For example, given a page named "Glossary",
+//
+//	See the Glossary for details.
+//
+// Will be transformed as if it was written as the following.
+//
+//	See the [[Glossary]] for details.
+//
+// Mentions must be whole words:
+// "Glossary" is not linked inside "Glossaryish".
+// Where mentions overlap, the one that starts first wins,
+// and among those, the longest.
+// Text inside headings, code, and existing links is left alone.
+// Mentions that span a line break are not linked.
+//
+// Install it on your goldmark Markdown object with Extender by setting
+// Extender.AutoLink, or install it directly on your goldmark Parser by using
+// the WithASTTransformers option.
+// goldmark runs transformers in increasing order of priority,
+// so use a lower priority than BatchTransformer
+// for the new wikilinks to be resolved with the rest.
+//
+//	autoLinker := util.Prioritized(&wikilink.AutoLinkTransformer{
+//		Pages: pages,
+//	}, 150)
+//	goldmarkParser.AddOptions(parser.WithASTTransformers(autoLinker))
+//
+// An AutoLinkTransformer is safe for concurrent use
+// once its fields are set.
+type AutoLinkTransformer struct {
+	// Pages lists the pages whose mentions should be linked.
+	// Mentions of a page's aliases link to its name.
+	// Headings are ignored.
+	//
+	// Where a name or alias belongs to more than one page,
+	// names take precedence over aliases,
+	// and earlier pages take precedence over later ones.
+	//
+	// Pages must not be changed after the first call to Transform.
+	Pages []IndexedPage
+
+	// FoldCase makes matching insensitive to ASCII case
+	// so that "glossary" also links to the page named "Glossary".
+	//
+	// FoldCase must not be changed after the first call to Transform.
+	FoldCase bool
+
+	// FirstOnly links only the first mention of each page in a document.
+	// Later mentions are left as plain text.
+	FirstOnly bool
+
+	once    sync.Once
+	matcher *acMatcher
+	pages   []int // page index for each pattern in matcher
+}
+
+var _ parser.ASTTransformer = (*AutoLinkTransformer)(nil)
+
+// autoLinkMatch is a mention of a page in a text node.
+type autoLinkMatch struct {
+	start, stop int // offsets in the source
+	page        int // index in Pages
+}
+
+// Transform links mentions of known pages in the provided document.
+func (t *AutoLinkTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	t.once.Do(t.build)
+	if len(t.pages) == 0 {
+		return
+	}
+
+	// Collect the text nodes first because splitting them
+	// while walking the tree would break the walk.
+	var texts []*ast.Text
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Heading, *ast.CodeSpan, *ast.CodeBlock, *ast.FencedCodeBlock,
+			*ast.HTMLBlock, *ast.RawHTML, *ast.Link, *ast.AutoLink, *ast.Image, *Node, *WikiWordEscape:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			if !n.IsRaw() {
+				texts = append(texts, n)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	src := reader.Source()
+	conv := getConversion(pc)
+	var linked map[int]struct{} // pages linked so far with FirstOnly
+	if t.FirstOnly {
+		linked = make(map[int]struct{})
+	}
+	for _, txt := range texts {
+		matches := t.find(src, txt.Segment)
+		if linked != nil {
+			first := matches[:0]
+			for _, m := range matches {
+				if _, ok := linked[m.page]; !ok {
+					linked[m.page] = struct{}{}
+					first = append(first, m)
+				}
+			}
+			matches = first
+		}
+		if len(matches) > 0 {
+			t.link(txt, matches, conv)
+		}
+	}
+}
+
+func (t *AutoLinkTransformer) build() {
+	var patterns [][]byte
+	// Names first so that they take precedence over aliases.
+	for i, page := range t.Pages {
+		if page.Name != "" {
+			patterns = append(patterns, []byte(page.Name))
+			t.pages = append(t.pages, i)
+		}
+	}
+	for i, page := range t.Pages {
+		for _, alias := range page.Aliases {
+			if alias != "" {
+				patterns = append(patterns, []byte(alias))
+				t.pages = append(t.pages, i)
+			}
+		}
+	}
+	t.matcher = newACMatcher(patterns, t.FoldCase)
+}
+
+// find returns the non-overlapping whole-word mentions of pages
+// in the text at seg, in order.
+func (t *AutoLinkTransformer) find(src []byte, seg text.Segment) []autoLinkMatch {
+	var matches []autoLinkMatch
+	t.matcher.each(seg.Value(src), func(pattern, end int) {
+		m := autoLinkMatch{
+			start: seg.Start + end - t.matcher.lens[pattern],
+			stop:  seg.Start + end,
+			page:  t.pages[pattern],
+		}
+		if isWordEdge(src, m.start) && isWordEdge(src, m.stop) {
+			matches = append(matches, m)
+		}
+	})
+
+	// Leftmost, then longest.
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].stop > matches[j].stop
+	})
+
+	var (
+		out  []autoLinkMatch
+		last = seg.Start
+	)
+	for _, m := range matches {
+		if m.start >= last {
+			out = append(out, m)
+			last = m.stop
+		}
+	}
+	return out
+}
+
+// link splits the provided text node around the provided matches,
+// replacing each with a wikilink.
+func (t *AutoLinkTransformer) link(txt *ast.Text, matches []autoLinkMatch, conv *conversion) {
+	parent := txt.Parent()
+	start := txt.Segment.Start
+	for _, m := range matches {
+		if start < m.start {
+			parent.InsertBefore(parent, txt, ast.NewTextSegment(text.NewSegment(start, m.start)))
+		}
+
+		seg := text.NewSegment(m.start, m.stop)
+		ln := &labeledNode{
+			node: Node{
+				Target:  []byte(t.Pages[m.page].Name),
+				Segment: seg,
+				conv:    conv,
+				bare:    true,
+			},
+		}
+		ln.label.Segment = seg
+
+		n := &ln.node
+		n.AppendChild(n, &ln.label)
+		parent.InsertBefore(parent, txt, n)
+		start = m.stop
+	}
+
+	// The original node keeps the rest of the text
+	// and any line break that follows it.
+	txt.Segment = text.NewSegment(start, txt.Segment.Stop)
+	if txt.Segment.IsEmpty() && !txt.SoftLineBreak() && !txt.HardLineBreak() {
+		parent.RemoveChild(parent, txt)
+	}
+}
+
+// isWordEdge reports whether the provided offset in src
+// is not between two letters or digits.
+func isWordEdge(src []byte, off int) bool {
+	before, _ := utf8.DecodeLastRune(src[:off])
+	after, _ := utf8.DecodeRune(src[off:])
+	return !isWordRune(before) || !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// acMatcher finds occurrences of a set of patterns in text
+// with the Aho-Corasick algorithm,
+// in time linear in the size of the text and the number of matches.
+type acMatcher struct {
+	foldCase bool
+	states   []acState
+	lens     []int // length of each pattern
+}
+
+// acState is a node in the trie of patterns.
+type acState struct {
+	next map[byte]int
+
+	// fail is the state for the longest proper suffix
+	// of this state's prefix that is also in the trie.
+	fail int
+
+	// match is the index of the pattern that ends at this state,
+	// or -1 if none does.
+	match int
+
+	// output is the nearest state along the fail links
+	// where a pattern ends, or -1 if there isn't one.
+	output int
+}
+
+func newACMatcher(patterns [][]byte, foldCase bool) *acMatcher {
+	m := &acMatcher{
+		foldCase: foldCase,
+		states:   []acState{{match: -1, output: -1}},
+		lens:     make([]int, len(patterns)),
+	}
+	for i, p := range patterns {
+		var s int
+		for _, c := range p {
+			c = m.fold(c)
+			next, ok := m.states[s].next[c]
+			if !ok {
+				next = len(m.states)
+				m.states = append(m.states, acState{match: -1, output: -1})
+				if m.states[s].next == nil {
+					m.states[s].next = make(map[byte]int)
+				}
+				m.states[s].next[c] = next
+			}
+			s = next
+		}
+		if m.states[s].match < 0 {
+			m.states[s].match = i // earlier patterns take precedence
+		}
+		m.lens[i] = len(p)
+	}
+
+	// Compute fail links breadth-first
+	// so that shorter prefixes are done before longer ones.
+	// States right below the root fail to the root.
+	var queue []int
+	for _, s := range m.states[0].next {
+		queue = append(queue, s)
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for c, next := range m.states[s].next {
+			f := m.states[s].fail
+			for f != 0 && !m.has(f, c) {
+				f = m.states[f].fail
+			}
+			if t, ok := m.states[f].next[c]; ok {
+				f = t
+			}
+
+			m.states[next].fail = f
+			if m.states[f].match >= 0 {
+				m.states[next].output = f
+			} else {
+				m.states[next].output = m.states[f].output
+			}
+			queue = append(queue, next)
+		}
+	}
+	return m
+}
+
+// each calls f for every occurrence of a pattern in b,
+// including overlapping ones,
+// with the index of the pattern and the offset in b where it ends.
+func (m *acMatcher) each(b []byte, f func(pattern, end int)) {
+	var s int
+	for i, c := range b {
+		c = m.fold(c)
+		for s != 0 && !m.has(s, c) {
+			s = m.states[s].fail
+		}
+		if next, ok := m.states[s].next[c]; ok {
+			s = next
+		}
+
+		if m.states[s].match >= 0 {
+			f(m.states[s].match, i+1)
+		}
+		for o := m.states[s].output; o >= 0; o = m.states[o].output {
+			f(m.states[o].match, i+1)
+		}
+	}
+}
+
+func (m *acMatcher) has(s int, c byte) bool {
+	_, ok := m.states[s].next[c]
+	return ok
+}
+
+func (m *acMatcher) fold(c byte) byte {
+	if m.foldCase && isUpper(c) {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,283 @@
+package wikilink
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+func TestAutoLinkTransformer(t *testing.T) {
+	t.Parallel()
+
+	glossary := []IndexedPage{
+		{Name: "Glossary"},
+		{Name: "Wiki", Aliases: []string{"wikis"}},
+		{Name: "Wiki Link", Aliases: []string{"wikilink"}},
+		{Name: "C++"},
+	}
+
+	tests := []struct {
+		desc      string
+		pages     []IndexedPage
+		foldCase  bool
+		firstOnly bool
+		give      string
+		want      string
+	}{
+		{
+			desc: "name",
+			give: "See the Glossary for details.",
+			want: `<p>See the <a href="Glossary.html">Glossary</a> for details.</p>`,
+		},
+		{
+			desc: "alias",
+			give: "About wikis.",
+			want: `<p>About <a href="Wiki.html">wikis</a>.</p>`,
+		},
+		{
+			desc: "whole text",
+			give: "Glossary",
+			want: `<p><a href="Glossary.html">Glossary</a></p>`,
+		},
+		{
+			desc: "longest",
+			give: "A Wiki Link in a Wiki.",
+			want: `<p>A <a href="Wiki%20Link.html">Wiki Link</a> in a <a href="Wiki.html">Wiki</a>.</p>`,
+		},
+		{
+			desc: "whole words",
+			give: "Glossaryish Wikipedia wikilinks C++x",
+			want: `<p>Glossaryish Wikipedia wikilinks <a href="C++.html">C++</a>x</p>`,
+		},
+		{
+			desc: "case sensitive",
+			give: "glossary",
+			want: `<p>glossary</p>`,
+		},
+		{
+			desc:     "fold case",
+			foldCase: true,
+			give:     "glossary and GLOSSARY",
+			want:     `<p><a href="Glossary.html">glossary</a> and <a href="Glossary.html">GLOSSARY</a></p>`,
+		},
+		{
+			desc:      "first only",
+			firstOnly: true,
+			give:      "Glossary, Wiki, wikis.\n\n*Glossary* and Wiki Link.",
+			want: "<p><a href=\"Glossary.html\">Glossary</a>, <a href=\"Wiki.html\">Wiki</a>, wikis.</p>\n" +
+				`<p><em>Glossary</em> and <a href="Wiki%20Link.html">Wiki Link</a>.</p>`,
+		},
+		{
+			desc: "emphasis",
+			give: "**Glossary**",
+			want: `<p><strong><a href="Glossary.html">Glossary</a></strong></p>`,
+		},
+		{
+			desc: "line breaks",
+			give: "Glossary\nand Wiki\nLink",
+			want: "<p><a href=\"Glossary.html\">Glossary</a>\nand <a href=\"Wiki.html\">Wiki</a>\nLink</p>",
+		},
+		{
+			desc: "heading",
+			give: "# Glossary",
+			want: `<h1>Glossary</h1>`,
+		},
+		{
+			desc: "code",
+			give: "`Glossary`\n\n    Glossary",
+			want: "<p><code>Glossary</code></p>\n<pre><code>Glossary\n</code></pre>",
+		},
+		{
+			desc: "links",
+			give: "[the Glossary](/g) [[Glossary]] [[Wiki|the Glossary]] <https://Glossary>",
+			want: `<p><a href="/g">the Glossary</a> <a href="Glossary.html">Glossary</a> ` +
+				`<a href="Wiki.html">the Glossary</a> <a href="https://Glossary">https://Glossary</a></p>`,
+		},
+		{
+			desc: "image",
+			give: "![the Glossary](g.png)",
+			want: `<p><img src="g.png" alt="the Glossary"></p>`,
+		},
+		{
+			desc: "name over alias",
+			pages: []IndexedPage{
+				{Name: "Foo", Aliases: []string{"Bar"}},
+				{Name: "Bar"},
+			},
+			give: "Bar",
+			want: `<p><a href="Bar.html">Bar</a></p>`,
+		},
+		{
+			desc:  "no pages",
+			pages: []IndexedPage{},
+			give:  "Glossary",
+			want:  `<p>Glossary</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			pages := tt.pages
+			if pages == nil {
+				pages = glossary
+			}
+
+			md := goldmark.New(goldmark.WithExtensions(&Extender{
+				AutoLink: &AutoLinkTransformer{
+					Pages:     pages,
+					FoldCase:  tt.foldCase,
+					FirstOnly: tt.firstOnly,
+				},
+			}))
+
+			var buf bytes.Buffer
+			require.NoError(t, md.Convert([]byte(tt.give), &buf))
+			assert.Equal(t, tt.want, strings.TrimSpace(buf.String()))
+		})
+	}
+}
+
+func TestAutoLinkTransformer_markdown(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("See wikis and the Glossary.")
+	doc := parser.NewParser(
+		parser.WithBlockParsers(parser.DefaultBlockParsers()...),
+		parser.WithInlineParsers(parser.DefaultInlineParsers()...),
+	).Parse(text.NewReader(src))
+
+	tr := AutoLinkTransformer{
+		Pages: []IndexedPage{
+			{Name: "Glossary"},
+			{Name: "Wiki", Aliases: []string{"wikis"}},
+		},
+	}
+	tr.Transform(doc.(*ast.Document), text.NewReader(src), parser.NewContext())
+
+	para := doc.FirstChild()
+	var got []string
+	for c := para.FirstChild(); c != nil; c = c.NextSibling() {
+		if n, ok := c.(*Node); ok {
+			got = append(got, renderMarkdown(t, src, n))
+		} else {
+			got = append(got, string(c.(*ast.Text).Segment.Value(src)))
+		}
+	}
+	assert.Equal(t, []string{"See ", "wikis", " and the ", "Glossary", "."}, got)
+}
+
+func TestAutoLinkTransformer_batchResolved(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&Extender{
+		Resolver: pathBatchResolver{},
+		AutoLink: &AutoLinkTransformer{
+			Pages: []IndexedPage{{Name: "Glossary"}},
+		},
+	}))
+
+	src := []byte("See [[Foo]] and the Glossary.")
+	doc := md.Parser().Parse(text.NewReader(src))
+
+	var got []string
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n, ok := n.(*Node); ok && entering {
+			require.NotNil(t, n.resolution, "%q was not batch-resolved", n.Target)
+			got = append(got, string(n.resolution.dest))
+		}
+		return ast.WalkContinue, nil
+	})
+	assert.Equal(t, []string{"/Foo", "/Glossary"}, got)
+}
+
+// pathBatchResolver is a BatchResolver that resolves targets
+// to absolute paths.
+type pathBatchResolver struct{}
+
+func (pathBatchResolver) ResolveWikilink(n *Node) ([]byte, error) {
+	return append([]byte("/"), n.Target...), nil
+}
+
+func (r pathBatchResolver) ResolveWikilinks(_ context.Context, nodes []*Node) ([][]byte, error) {
+	dests := make([][]byte, len(nodes))
+	for i, n := range nodes {
+		dests[i], _ = r.ResolveWikilink(n)
+	}
+	return dests, nil
+}
+
+func TestACMatcher(t *testing.T) {
+	t.Parallel()
+
+	patterns := [][]byte{
+		[]byte("he"),
+		[]byte("she"),
+		[]byte("his"),
+		[]byte("hers"),
+		[]byte("he"), // duplicate
+	}
+
+	type match struct {
+		Pattern string
+		End     int
+	}
+
+	tests := []struct {
+		desc     string
+		foldCase bool
+		give     string
+		want     []match
+	}{
+		{
+			desc: "overlapping",
+			give: "ushers",
+			want: []match{{"she", 4}, {"he", 4}, {"hers", 6}},
+		},
+		{
+			desc: "repeated",
+			give: "hehe his",
+			want: []match{{"he", 2}, {"he", 4}, {"his", 8}},
+		},
+		{
+			desc: "case sensitive",
+			give: "SHE",
+		},
+		{
+			desc:     "fold case",
+			foldCase: true,
+			give:     "SHE",
+			want:     []match{{"she", 3}, {"he", 3}},
+		},
+		{
+			desc: "no match",
+			give: "abc",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			m := newACMatcher(patterns, tt.foldCase)
+			var got []match
+			m.each([]byte(tt.give), func(pattern, end int) {
+				assert.NotEqual(t, 4, pattern, "duplicate pattern must not match")
+				got = append(got, match{string(patterns[pattern]), end})
+			})
+
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
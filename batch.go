@@ -0,0 +1,115 @@
+package wikilink
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// BatchResolver is a Resolver that can resolve
+// all wikilinks in a document at once.
+// Implement this for resolvers that look up destinations
+// in an index or a database to avoid a round trip per wikilink.
+//
+// When Extender is given a BatchResolver,
+// it installs a BatchTransformer that resolves all wikilinks in a document
+// right after it's parsed.
+// The Renderer then uses those results instead of resolving wikilinks
+// one at a time.
+type BatchResolver interface {
+	Resolver
+
+	// ResolveWikilinks returns the destinations for the provided wikilinks.
+	// The returned slice must have the same length as the input,
+	// with destinations in the same order.
+	//
+	// Wikilinks are deduplicated by target and fragment before
+	// ResolveWikilinks is called.
+	//
+	// The context is the one specified for the conversion with SetContext,
+	// or context.Background() if none was specified.
+	//
+	// Nil destinations and errors are handled
+	// the same as with ResolveWikilink.
+	// An error applies to all wikilinks in the batch.
+	ResolveWikilinks(ctx context.Context, nodes []*Node) (destinations [][]byte, err error)
+}
+
+// resolution is the result of resolving a wikilink ahead of rendering.
+type resolution struct {
+	dest []byte
+	err  error
+}
+
+// BatchTransformer is a goldmark AST transformer that resolves
+// all wikilinks in a document in a single call to a BatchResolver.
+// Results are stored on the wikilink nodes,
+// and used by the Renderer instead of calling its Resolver.
+//
+// Extender installs a BatchTransformer automatically
+// if its Resolver is a BatchResolver.
+// To install it directly on your goldmark Parser,
+// use the WithASTTransformers option.
+// goldmark runs transformers in increasing order of priority,
+// so use a high priority to run it after other transformers
+// that may add or remove wikilinks.
+//
+//	batchTransformer := util.Prioritized(&wikilink.BatchTransformer{...}, 1000)
+//	goldmarkParser.AddOptions(parser.WithASTTransformers(batchTransformer))
+type BatchTransformer struct {
+	// Resolver resolves the wikilinks in the document.
+	Resolver BatchResolver
+}
+
+var _ parser.ASTTransformer = (*BatchTransformer)(nil)
+
+// Transform resolves all wikilinks in the provided document.
+func (t *BatchTransformer) Transform(doc *ast.Document, _ text.Reader, pc parser.Context) {
+	type key struct{ target, fragment string }
+
+	var (
+		nodes   []*Node // unique wikilinks
+		results []*resolution
+		seen    = make(map[key]*resolution)
+	)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		link, ok := n.(*Node)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+
+		k := key{string(link.Target), string(link.Fragment)}
+		res, ok := seen[k]
+		if !ok {
+			res = new(resolution)
+			seen[k] = res
+			nodes = append(nodes, link)
+			results = append(results, res)
+		}
+		link.resolution = res
+		return ast.WalkContinue, nil
+	})
+	if len(nodes) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+	if c := getConversion(pc); c != nil && c.ctx != nil {
+		ctx = c.ctx
+	}
+
+	dests, err := t.Resolver.ResolveWikilinks(ctx, nodes)
+	if err == nil && len(dests) != len(nodes) {
+		err = fmt.Errorf("batch resolver returned %d destinations for %d wikilinks", len(dests), len(nodes))
+	}
+	for i, res := range results {
+		if err != nil {
+			res.err = err
+		} else {
+			res.dest = dests[i]
+		}
+	}
+}
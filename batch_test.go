@@ -0,0 +1,180 @@
+package wikilink_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+// batchResolver is a BatchResolver that records the wikilinks it was asked
+// to resolve.
+type batchResolver struct {
+	t testing.TB
+
+	calls   int
+	targets []string
+	ctx     context.Context
+	err     error
+	short   bool // return too few destinations
+}
+
+func (r *batchResolver) ResolveWikilink(*wikilink.Node) ([]byte, error) {
+	r.t.Errorf("ResolveWikilink must not be called")
+	return nil, nil
+}
+
+func (r *batchResolver) ResolveWikilinks(ctx context.Context, nodes []*wikilink.Node) ([][]byte, error) {
+	r.calls++
+	r.ctx = ctx
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	dests := make([][]byte, 0, len(nodes))
+	for _, n := range nodes {
+		target := string(n.Target)
+		if len(n.Fragment) > 0 {
+			target += "#" + string(n.Fragment)
+		}
+		r.targets = append(r.targets, target)
+
+		var dest []byte
+		if target != "Missing" {
+			dest = []byte("/" + target)
+		}
+		dests = append(dests, dest)
+	}
+	if r.short {
+		dests = dests[:len(dests)-1]
+	}
+	return dests, nil
+}
+
+func TestBatchResolver(t *testing.T) {
+	t.Parallel()
+
+	resolver := &batchResolver{t: t}
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Resolver: resolver,
+	}))
+
+	src := "[[Foo]] [[Bar]] [[Foo|foo]]\n\n" +
+		"- [[Foo#Baz]]\n" +
+		"- [[Missing]]\n" +
+		"- ![[Foo]]\n"
+
+	pc := parser.NewContext()
+	ctx := context.WithValue(context.Background(), ctxKey{}, "hello")
+	wikilink.SetContext(pc, ctx)
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte(src), &buf, parser.WithContext(pc)))
+	assert.Equal(t,
+		`<p><a href="/Foo">Foo</a> <a href="/Bar">Bar</a> <a href="/Foo">foo</a></p>`+"\n"+
+			"<ul>\n"+
+			`<li><a href="/Foo#Baz">Foo#Baz</a></li>`+"\n"+
+			"<li>Missing</li>\n"+
+			`<li><a href="/Foo">Foo</a></li>`+"\n"+
+			"</ul>\n",
+		buf.String())
+
+	assert.Equal(t, 1, resolver.calls, "resolver calls")
+	assert.Equal(t, []string{"Foo", "Bar", "Foo#Baz", "Missing"}, resolver.targets)
+	assert.Equal(t, "hello", resolver.ctx.Value(ctxKey{}), "context")
+}
+
+func TestBatchResolver_noLinks(t *testing.T) {
+	t.Parallel()
+
+	resolver := &batchResolver{t: t}
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Resolver: resolver,
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte("Hello"), &buf))
+	assert.Zero(t, resolver.calls, "resolver must not be called")
+}
+
+func TestBatchResolver_transformers(t *testing.T) {
+	t.Parallel()
+
+	// Wikilinks added by other transformers are resolved in the batch.
+	resolver := &batchResolver{t: t}
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Resolver: resolver,
+		Figures:  true,
+		AutoLink: &wikilink.AutoLinkTransformer{
+			Pages: []wikilink.IndexedPage{{Name: "Glossary"}},
+		},
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte("See [[Foo]] and the Glossary."), &buf))
+	assert.Equal(t,
+		`<p>See <a href="/Foo">Foo</a> and the <a href="/Glossary">Glossary</a>.</p>`+"\n",
+		buf.String())
+
+	assert.Equal(t, 1, resolver.calls, "resolver calls")
+	assert.Equal(t, []string{"Foo", "Glossary"}, resolver.targets)
+}
+
+func TestBatchResolver_errors(t *testing.T) {
+	t.Parallel()
+
+	giveErr := errors.New("great sadness")
+	tests := []struct {
+		desc     string
+		resolver *batchResolver
+		wantErr  string
+	}{
+		{
+			desc:     "resolver error",
+			resolver: &batchResolver{err: giveErr},
+			wantErr:  "great sadness",
+		},
+		{
+			desc:     "too few destinations",
+			resolver: &batchResolver{short: true},
+			wantErr:  "batch resolver returned 1 destinations for 2 wikilinks",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			tt.resolver.t = t
+
+			t.Run("abort", func(t *testing.T) {
+				md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+					Resolver: tt.resolver,
+				}))
+
+				var buf bytes.Buffer
+				err := md.Convert([]byte("[[Foo]] [[Bar]]"), &buf)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			})
+
+			t.Run("policy", func(t *testing.T) {
+				md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+					Resolver:    tt.resolver,
+					ErrorPolicy: wikilink.RenderErrorAsText,
+				}))
+
+				var buf bytes.Buffer
+				require.NoError(t, md.Convert([]byte("[[Foo]] [[Bar]]"), &buf))
+				assert.Equal(t, "<p>Foo Bar</p>\n", buf.String())
+			})
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package wikilink
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+)
+
+// _benchmarkCorpora are the corpora that benchmarks run against.
+var _benchmarkCorpora = []struct {
+	name string
+	src  []byte
+	// Number of wikilinks in src.
+	links int
+}{
+	{name: "note", src: benchmarkDocument(20), links: 20},
+	{name: "long-note", src: benchmarkDocument(1000), links: 1000},
+	{name: "index", src: benchmarkDocument(10000), links: 10000},
+}
+
+func BenchmarkConvert(b *testing.B) {
+	md := goldmark.New(goldmark.WithExtensions(&Extender{}))
+	for _, corpus := range _benchmarkCorpora {
+		b.Run(corpus.name, func(b *testing.B) {
+			b.SetBytes(int64(len(corpus.src)))
+			reportAllocsPerLink(b, corpus.links, func() {
+				if err := md.Convert(corpus.src, io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkConvert_logseqNesting converts deeply nested wikilinks
+// in the LogseqDialect, with and without closing brackets.
+// Parsing them should take time linear in the length of the input,
+// apart from the time goldmark spends on "[" and "]" itself.
+func BenchmarkConvert_logseqNesting(b *testing.B) {
+	md := goldmark.New(goldmark.WithExtensions(&Extender{Dialect: LogseqDialect}))
+	for _, depth := range []int{_maxLogseqDepth, 100, 1000} {
+		for _, closed := range []bool{true, false} {
+			src := []byte(strings.Repeat("[[a", depth))
+			if closed {
+				src = append(src, strings.Repeat("]]", depth)...)
+			}
+
+			b.Run(fmt.Sprintf("depth=%d/closed=%v", depth, closed), func(b *testing.B) {
+				b.SetBytes(int64(len(src)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := md.Convert(src, io.Discard); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// reportAllocsPerLink runs f b.N times,
+// reporting the number of allocations per wikilink
+// in addition to the usual allocation metrics.
+func reportAllocsPerLink(b *testing.B, links int, f func()) {
+	b.ReportAllocs()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f()
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+
+	allocs := float64(after.Mallocs - before.Mallocs)
+	b.ReportMetric(allocs/float64(b.N*links), "allocs/link")
+}
+
+// benchmarkDocument generates a Markdown document
+// with the given number of wikilinks of varying forms,
+// mixed with regular Markdown that exercises the same parser triggers.
+func benchmarkDocument(numLinks int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < numLinks; i++ {
+		if i%10 == 0 {
+			fmt.Fprintf(&buf, "\n## Section %d\n\n", i/10)
+			buf.WriteString("Some prose with a [regular link](https://example.com/) " +
+				"and an exclamation! Also `[[code]]` and [a reference][ref].\n\n")
+		}
+
+		switch i % 5 {
+		case 0:
+			fmt.Fprintf(&buf, "See [[Page %d]] for details. ", i)
+		case 1:
+			fmt.Fprintf(&buf, "Read [[Page %d|the page]] first. ", i)
+		case 2:
+			fmt.Fprintf(&buf, "Jump to [[Page %d#Section %d]]. ", i, i%7)
+		case 3:
+			fmt.Fprintf(&buf, "Related: [[notes/Page %d#Intro|intro]].\n", i)
+		case 4:
+			fmt.Fprintf(&buf, "\n![[image-%d.png|Figure %d]]\n", i, i)
+		}
+	}
+	buf.WriteString("\n[ref]: https://example.com/ref\n")
+	return buf.Bytes()
+}
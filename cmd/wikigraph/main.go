@@ -0,0 +1,115 @@
+// wikigraph exports the graph of wikilinks between Markdown notes
+// for visualization.
+//
+// Usage:
+//
+//	wikigraph [-format dot|json|graphml] [-folder DIR]... [-tag TAG]... [-o FILE] VAULT
+//
+// The graph is written in one of the following formats:
+//
+//   - dot: Graphviz DOT (default)
+//   - json: a JSON object with "nodes" and "links" for d3-force
+//   - graphml: GraphML
+//
+// Use -folder and -tag to include only the notes
+// in the given folders or with the given tags.
+// Both flags may be repeated.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.abhg.dev/goldmark/wikilink/graph"
+)
+
+func main() {
+	cmd := mainCmd{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	if err := cmd.Run(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "wikigraph:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// _formats maps names of output formats to their writers.
+var _formats = map[string]func(*graph.Graph, io.Writer) error{
+	"dot":     (*graph.Graph).WriteDOT,
+	"json":    (*graph.Graph).WriteJSON,
+	"graphml": (*graph.Graph).WriteGraphML,
+}
+
+type mainCmd struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (cmd *mainCmd) Run(args []string) (err error) {
+	flag := flag.NewFlagSet("wikigraph", flag.ContinueOnError)
+	flag.SetOutput(cmd.Stderr)
+	flag.Usage = func() {
+		fmt.Fprintln(flag.Output(), "usage: wikigraph [options] VAULT")
+		flag.PrintDefaults()
+	}
+
+	var filter graph.Filter
+	format := flag.String("format", "dot", "write the graph as `FORMAT`: dot, json, or graphml")
+	output := flag.String("o", "", "write the graph to `FILE` instead of stdout")
+	flag.Var((*stringList)(&filter.Folders), "folder", "include only notes inside `DIR` (repeatable)")
+	flag.Var((*stringList)(&filter.Tags), "tag", "include only notes tagged with `TAG` (repeatable)")
+	if err := flag.Parse(args); err != nil {
+		return err
+	}
+	if flag.NArg() != 1 {
+		flag.Usage()
+		return errors.New("expected exactly one VAULT argument")
+	}
+
+	write, ok := _formats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	g, err := graph.Load(os.DirFS(flag.Arg(0)))
+	if err != nil {
+		return err
+	}
+	g = g.Filter(filter)
+
+	w := cmd.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+
+	return write(g, w)
+}
+
+// stringList is a flag.Value that accumulates strings
+// from repeated uses of a flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Home.md":      "[[Foo]] #index",
+		"notes/Foo.md": "![[img.png]] #draft",
+		"notes/Bar.md": "[[Home#Top]]",
+		"img.png":      "png",
+	})
+
+	tests := []struct {
+		desc string
+		args []string
+		want string
+	}{
+		{
+			desc: "dot",
+			want: `digraph wikilinks {
+	"Home.md" [label="Home"];
+	"img.png" [label="img.png", shape=box];
+	"notes/Bar.md" [label="Bar"];
+	"notes/Foo.md" [label="Foo"];
+	"Home.md" -> "notes/Foo.md";
+	"notes/Bar.md" -> "Home.md" [label="Top"];
+	"notes/Foo.md" -> "img.png" [style=dashed];
+}
+`,
+		},
+		{
+			desc: "folder",
+			args: []string{"-folder", "notes"},
+			want: `digraph wikilinks {
+	"img.png" [label="img.png", shape=box];
+	"notes/Bar.md" [label="Bar"];
+	"notes/Foo.md" [label="Foo"];
+	"notes/Foo.md" -> "img.png" [style=dashed];
+}
+`,
+		},
+		{
+			desc: "tags",
+			args: []string{"-tag", "index", "-tag", "draft", "-format", "json"},
+			want: `{
+  "nodes": [
+    {
+      "id": "Home.md",
+      "label": "Home",
+      "kind": "page",
+      "group": "",
+      "tags": [
+        "index"
+      ]
+    },
+    {
+      "id": "img.png",
+      "label": "img.png",
+      "kind": "attachment",
+      "group": ""
+    },
+    {
+      "id": "notes/Foo.md",
+      "label": "Foo",
+      "kind": "page",
+      "group": "notes",
+      "tags": [
+        "draft"
+      ]
+    }
+  ],
+  "links": [
+    {
+      "source": "Home.md",
+      "target": "notes/Foo.md"
+    },
+    {
+      "source": "notes/Foo.md",
+      "target": "img.png",
+      "embed": true
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			cmd := mainCmd{Stdout: &stdout, Stderr: &stderr}
+			require.NoError(t, cmd.Run(append(tt.args, dir)))
+			assert.Equal(t, tt.want, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
+
+func TestRun_output(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Home.md": "[[Home]]",
+	})
+	out := filepath.Join(t.TempDir(), "graph.graphml")
+
+	var stdout, stderr bytes.Buffer
+	cmd := mainCmd{Stdout: &stdout, Stderr: &stderr}
+	require.NoError(t, cmd.Run([]string{"-format", "graphml", "-o", out, dir}))
+	assert.Empty(t, stdout.String())
+
+	got, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(got), `<edge source="Home.md" target="Home.md"></edge>`)
+}
+
+func TestRun_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		args []string
+		want string
+	}{
+		{desc: "no vault", want: "expected exactly one VAULT argument"},
+		{desc: "bad format", args: []string{"-format", "svg", "."}, want: `unknown format "svg"`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			cmd := mainCmd{Stdout: &stdout, Stderr: &stderr}
+			err := cmd.Run(tt.args)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func writeFiles(t testing.TB, dir string, files map[string]string) {
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(body), 0o644))
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"go.abhg.dev/goldmark/wikilink"
+	"go.abhg.dev/goldmark/wikilink/internal/vault"
+)
+
+// _parser parses Markdown documents with wikilinks.
+var _parser = parser.NewParser(
+	parser.WithBlockParsers(parser.DefaultBlockParsers()...),
+	parser.WithInlineParsers(append(
+		parser.DefaultInlineParsers(),
+		util.Prioritized(&wikilink.Parser{}, 199),
+	)...),
+	parser.WithParagraphTransformers(parser.DefaultParagraphTransformers()...),
+)
+
+// document is a parsed Markdown document.
+type document struct {
+	Text []byte
+
+	// Links are the wikilinks in the document,
+	// in the order they appear.
+	Links []*wikilink.Node
+
+	// Headings are the headings in the document,
+	// in the order they appear.
+	Headings []heading
+
+	lines []int // offsets at which lines start
+}
+
+// heading is a heading in a document.
+type heading struct {
+	Text       string
+	Start, End int // byte offsets of the heading text
+}
+
+func parseDocument(src []byte) *document {
+	doc := document{
+		Text:  src,
+		lines: []int{0},
+	}
+	for i, c := range src {
+		if c == '\n' {
+			doc.lines = append(doc.lines, i+1)
+		}
+	}
+
+	root := _parser.Parse(text.NewReader(src))
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *wikilink.Node:
+			doc.Links = append(doc.Links, n)
+			return ast.WalkSkipChildren, nil
+
+		case *ast.Heading:
+			if lines := n.Lines(); lines.Len() > 0 {
+				seg := lines.At(0)
+				doc.Headings = append(doc.Headings, heading{
+					Text:  string(bytes.TrimSpace(seg.Value(src))),
+					Start: seg.Start,
+					End:   seg.Stop,
+				})
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return &doc
+}
+
+// LinkAt returns the wikilink at the provided byte offset,
+// or nil if there isn't one.
+// The offset right after a wikilink is considered part of it
+// so that a cursor placed after "]]" finds the link.
+func (d *document) LinkAt(off int) *wikilink.Node {
+	for _, n := range d.Links {
+		if n.Segment.Start <= off && off <= n.Segment.Stop {
+			return n
+		}
+	}
+	return nil
+}
+
+// Heading returns the heading that a wikilink fragment refers to.
+func (d *document) Heading(fragment string) (heading, bool) {
+	id := vault.HeadingID(fragment)
+	for _, h := range d.Headings {
+		if vault.HeadingID(h.Text) == id {
+			return h, true
+		}
+	}
+	return heading{}, false
+}
+
+// targetRange returns the byte offsets of the target of a wikilink,
+// not including the fragment.
+//
+//	[[foo#bar|baz]]
+//	  ^^^
+func targetRange(n *wikilink.Node) (start, end int) {
+	start = n.Segment.Start + len("[[")
+	if n.Embed {
+		start++
+	}
+	return start, start + len(n.Target)
+}
+
+// Range returns the range between the provided byte offsets.
+func (d *document) Range(start, end int) lspRange {
+	return lspRange{
+		Start: d.Position(start),
+		End:   d.Position(end),
+	}
+}
+
+// Position returns the position of the provided byte offset.
+func (d *document) Position(off int) position {
+	off = min(max(off, 0), len(d.Text))
+	line := d.line(off)
+
+	var col int
+	for _, r := range string(d.Text[d.lines[line]:off]) {
+		col += utf16Len(r)
+	}
+	return position{Line: line, Character: col}
+}
+
+// Offset returns the byte offset of the provided position.
+// Positions past the end of a line refer to the end of the line.
+func (d *document) Offset(pos position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.Text)
+	}
+
+	off := d.lines[pos.Line]
+	for col := 0; col < pos.Character && off < len(d.Text); {
+		r, size := utf8.DecodeRune(d.Text[off:])
+		if r == '\n' || (r == '\r' && off+1 < len(d.Text) && d.Text[off+1] == '\n') {
+			break
+		}
+		col += utf16Len(r)
+		off += size
+	}
+	return off
+}
+
+// LineStart returns the byte offset of the start of the line
+// containing the provided offset.
+func (d *document) LineStart(off int) int {
+	return d.lines[d.line(off)]
+}
+
+// line returns the 0-indexed line containing the provided byte offset.
+func (d *document) line(off int) int {
+	return sort.Search(len(d.lines), func(i int) bool {
+		return d.lines[i] > off
+	}) - 1
+}
+
+// utf16Len returns the number of UTF-16 code units
+// needed to encode the provided rune.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2 // surrogate pair
+	}
+	return 1
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_positions(t *testing.T) {
+	t.Parallel()
+
+	// "é" is 2 bytes and 1 UTF-16 code unit.
+	// "😀" is 4 bytes and 2 UTF-16 code units.
+	doc := parseDocument([]byte("é [[a]]\r\n😀 [[b]]\n"))
+
+	tests := []struct {
+		off int
+		pos position
+	}{
+		{0, position{0, 0}},
+		{2, position{0, 1}},
+		{3, position{0, 2}},
+		{8, position{0, 7}},
+		{10, position{1, 0}},
+		{14, position{1, 2}},
+		{15, position{1, 3}},
+		{21, position{2, 0}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.pos, doc.Position(tt.off), "Position(%d)", tt.off)
+		assert.Equal(t, tt.off, doc.Offset(tt.pos), "Offset(%v)", tt.pos)
+	}
+
+	assert.Equal(t, 8, doc.Offset(position{0, 100}), "past end of line")
+	assert.Equal(t, 21, doc.Offset(position{5, 0}), "past end of document")
+	assert.Equal(t, 10, doc.LineStart(17))
+}
+
+func TestDocument_links(t *testing.T) {
+	t.Parallel()
+
+	doc := parseDocument([]byte(
+		"# Intro\n\n" +
+			"[[foo#Bar|baz]] and ![[img.png]]\n\n" +
+			"`[[not a link]]`\n\n" +
+			"## Café au lait\n",
+	))
+
+	require.Len(t, doc.Links, 2)
+	foo, img := doc.Links[0], doc.Links[1]
+
+	assert.Same(t, foo, doc.LinkAt(9))
+	assert.Same(t, foo, doc.LinkAt(24), "right after the link")
+	assert.Nil(t, doc.LinkAt(26))
+	assert.Same(t, img, doc.LinkAt(30))
+
+	start, end := targetRange(foo)
+	assert.Equal(t, "foo", string(doc.Text[start:end]))
+	start, end = targetRange(img)
+	assert.Equal(t, "img.png", string(doc.Text[start:end]))
+
+	assert.Equal(t, []heading{
+		{Text: "Intro", Start: 2, End: 7},
+		{Text: "Café au lait", Start: 64, End: 77},
+	}, doc.Headings)
+
+	h, ok := doc.Heading("café-AU lait")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Café au lait", h.Text)
+	}
+	_, ok = doc.Heading("Outro")
+	assert.False(t, ok)
+}
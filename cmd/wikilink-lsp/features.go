@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"go.abhg.dev/goldmark/wikilink"
+	"go.abhg.dev/goldmark/wikilink/internal/vault"
+)
+
+// completion suggests targets for a wikilink being typed.
+//
+//	[[Fo|      => pages and attachments matching "Fo"
+//	[[Foo#Ba|  => headings in Foo matching "Ba"
+func (s *server) completion(params *textDocumentPositionParams) (any, error) {
+	p, doc, err := s.openDocument(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	off := doc.Offset(params.Position)
+	line := doc.Text[doc.LineStart(off):off]
+	start := bytes.LastIndex(line, []byte("[["))
+	if start < 0 {
+		return nil, nil
+	}
+	partial := line[start+len("[["):]
+	if bytes.Contains(partial, []byte("]]")) || bytes.IndexByte(partial, '|') >= 0 {
+		return nil, nil // not inside a target
+	}
+	partialStart := off - len(partial)
+
+	list := completionList{Items: []completionItem{}}
+	if idx := bytes.LastIndexByte(partial, '#'); idx >= 0 {
+		target := string(partial[:idx])
+		fragment := partial[idx+1:]
+
+		// [[#...]] refers to the current page.
+		headings := doc.Headings
+		if target != "" {
+			to, ok := s.vault.Lookup(target)
+			if !ok || !vault.IsPage(to) {
+				return list, nil
+			}
+			targetDoc, err := s.document(to)
+			if err != nil {
+				return nil, err
+			}
+			headings = targetDoc.Headings
+		}
+
+		editRange := doc.Range(partialStart+idx+1, off)
+		for _, h := range headings {
+			if matches(h.Text, fragment) {
+				list.Items = append(list.Items, completionItem{
+					Label:    h.Text,
+					Kind:     _completionFile,
+					TextEdit: &textEdit{Range: editRange, NewText: h.Text},
+				})
+			}
+		}
+		return list, nil
+	}
+
+	// Use the shortest unambiguous target for each file:
+	// the name if it's unique, and the full path otherwise.
+	files := append(append([]string(nil), s.vault.Pages()...), s.vault.Attachments()...)
+	names := make(map[string]int)
+	for _, f := range files {
+		names[vault.Name(f)]++
+	}
+
+	editRange := doc.Range(partialStart, off)
+	for _, f := range files {
+		if f == p {
+			continue
+		}
+
+		name := vault.Name(f)
+		insert := name
+		if names[name] > 1 {
+			insert = linkPath(f)
+		}
+		if !matches(insert, partial) {
+			continue
+		}
+
+		list.Items = append(list.Items, completionItem{
+			Label:    insert,
+			Kind:     _completionFile,
+			Detail:   f,
+			TextEdit: &textEdit{Range: editRange, NewText: insert},
+		})
+	}
+	return list, nil
+}
+
+// matches reports whether a completion candidate matches
+// the partial text typed by the user.
+// Matching ignores case and spacing differences.
+func matches(candidate string, partial []byte) bool {
+	return strings.Contains(
+		wikilink.DefaultNormalizer.Key([]byte(candidate)),
+		wikilink.DefaultNormalizer.Key(partial),
+	)
+}
+
+// definition finds the file that the wikilink at the position refers to.
+// If the wikilink has a fragment, the matching heading is used.
+func (s *server) definition(params *textDocumentPositionParams) (any, error) {
+	p, doc, err := s.openDocument(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	n := doc.LinkAt(doc.Offset(params.Position))
+	if n == nil {
+		return nil, nil
+	}
+
+	to, targetDoc := p, doc
+	if len(n.Target) > 0 {
+		var ok bool
+		to, ok = s.vault.Lookup(string(n.Target))
+		if !ok {
+			return nil, nil
+		}
+
+		targetDoc = nil
+		if vault.IsPage(to) {
+			targetDoc, err = s.document(to)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	loc := location{URI: s.uri(to)}
+	if targetDoc != nil && len(n.Fragment) > 0 {
+		if h, ok := targetDoc.Heading(string(n.Fragment)); ok {
+			loc.Range = targetDoc.Range(h.Start, h.End)
+		}
+	}
+	return []location{loc}, nil
+}
+
+// references finds all wikilinks to a file.
+// The file is the target of the wikilink at the position,
+// or the current document if the position is not on a wikilink.
+func (s *server) references(params *referenceParams) (any, error) {
+	to, err := s.subject(&params.textDocumentPositionParams)
+	if err != nil || to == "" {
+		return nil, err
+	}
+
+	locs := []location{}
+	if params.Context.IncludeDeclaration {
+		locs = append(locs, location{URI: s.uri(to)})
+	}
+
+	err = s.eachLinkTo(to, func(from string, doc *document, n *wikilink.Node) {
+		locs = append(locs, location{
+			URI:   s.uri(from),
+			Range: doc.Range(n.Segment.Start, n.Segment.Stop),
+		})
+	})
+	return locs, err
+}
+
+// rename renames a file and updates all wikilinks to it.
+// The file is the target of the wikilink at the position,
+// or the current document if the position is not on a wikilink.
+//
+// The new name may be a name for the file in the same directory,
+// or a path relative to the root of the vault.
+func (s *server) rename(params *renameParams) (any, error) {
+	from, err := s.subject(&params.textDocumentPositionParams)
+	if err != nil {
+		return nil, err
+	}
+	if from == "" {
+		return nil, &rpcError{Code: _invalidRequest, Message: "no wikilink target at position"}
+	}
+
+	to, err := renamedPath(from, params.NewName)
+	if err != nil {
+		return nil, &rpcError{Code: _invalidParams, Message: err.Error()}
+	}
+	if found, ok := s.vault.Lookup(to); ok && found != from {
+		return nil, &rpcError{Code: _invalidRequest, Message: fmt.Sprintf("%v already exists", found)}
+	}
+
+	edits := make(map[string][]textEdit)
+	var docs []string // in order of first edit
+	err = s.eachLinkTo(from, func(p string, doc *document, n *wikilink.Node) {
+		newTarget := vault.Name(to)
+		if bytes.IndexByte(n.Target, '/') >= 0 {
+			newTarget = linkPath(to)
+		}
+
+		if _, ok := edits[p]; !ok {
+			docs = append(docs, p)
+		}
+		edits[p] = append(edits[p], textEdit{
+			Range:   doc.Range(targetRange(n)),
+			NewText: newTarget,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.renameFiles {
+		changes := make(map[string][]textEdit, len(edits))
+		for p, e := range edits {
+			changes[s.uri(p)] = e
+		}
+		return &workspaceEdit{Changes: changes}, nil
+	}
+
+	changes := make([]any, 0, len(docs)+1)
+	for _, p := range docs {
+		id := versionedTextDocumentIdentifier{URI: s.uri(p)}
+		if doc, ok := s.open[p]; ok {
+			version := doc.Version
+			id.Version = &version
+		}
+		changes = append(changes, &textDocumentEdit{TextDocument: id, Edits: edits[p]})
+	}
+	changes = append(changes, &renameFile{
+		Kind:   "rename",
+		OldURI: s.uri(from),
+		NewURI: s.uri(to),
+	})
+	return &workspaceEdit{DocumentChanges: changes}, nil
+}
+
+// renamedPath returns the new path for a file
+// renamed to newName.
+func renamedPath(from, newName string) (string, error) {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return "", fmt.Errorf("name must not be empty")
+	}
+	if strings.ContainsAny(newName, "[]|#") || strings.Contains(newName, "..") {
+		return "", fmt.Errorf("name %q must not contain '[', ']', '|', '#', or '..'", newName)
+	}
+
+	to := strings.Trim(newName, "/")
+	if !strings.Contains(to, "/") {
+		to = path.Join(path.Dir(from), to)
+	}
+	if ext := path.Ext(from); vault.IsPage(from) && !vault.IsPage(to) {
+		to += ext
+	}
+	return to, nil
+}
+
+// subject returns the path of the file that a request at a position
+// is about: the target of the wikilink at the position,
+// or the current document if the position is not on a wikilink.
+//
+// It returns an empty path if the wikilink does not resolve.
+func (s *server) subject(params *textDocumentPositionParams) (string, error) {
+	p, doc, err := s.openDocument(params.TextDocument.URI)
+	if err != nil {
+		return "", err
+	}
+
+	n := doc.LinkAt(doc.Offset(params.Position))
+	if n == nil || len(n.Target) == 0 {
+		return p, nil
+	}
+
+	to, ok := s.vault.Lookup(string(n.Target))
+	if !ok {
+		return "", nil
+	}
+	return to, nil
+}
+
+// eachLinkTo calls f for every wikilink in the vault
+// that refers to the file at the provided path,
+// in the order of the pages and the links in them.
+func (s *server) eachLinkTo(to string, f func(from string, doc *document, n *wikilink.Node)) error {
+	for _, from := range s.pages() {
+		doc, err := s.document(from)
+		if err != nil {
+			return err
+		}
+
+		for _, n := range doc.Links {
+			if len(n.Target) == 0 {
+				continue
+			}
+			if target, ok := s.vault.Lookup(string(n.Target)); ok && target == to {
+				f(from, doc, n)
+			}
+		}
+	}
+	return nil
+}
+
+// pages returns the paths of all pages in the vault,
+// including open documents that haven't been saved yet.
+func (s *server) pages() []string {
+	pages := append([]string(nil), s.vault.Pages()...)
+	for p := range s.open {
+		if _, ok := s.vault.Lookup(p); !ok && vault.IsPage(p) {
+			pages = append(pages, p)
+		}
+	}
+	sort.Strings(pages)
+	return pages
+}
+
+// publishDiagnostics reports broken wikilinks in an open document:
+// wikilinks to files that don't exist,
+// and to headings that don't exist.
+func (s *server) publishDiagnostics(p string) error {
+	doc, ok := s.open[p]
+	if !ok {
+		return nil
+	}
+
+	diags := []diagnostic{}
+	for _, n := range doc.Links {
+		var msg string
+		switch target, ok := s.vault.Lookup(string(n.Target)); {
+		case len(n.Target) > 0 && !ok:
+			msg = fmt.Sprintf("unresolved wikilink [[%s]]", n.Target)
+
+		case len(n.Fragment) > 0 && n.Fragment[0] != '^' && (len(n.Target) == 0 || vault.IsPage(target)):
+			// Block references (#^id) are not checked.
+			targetDoc := doc.document
+			if len(n.Target) > 0 {
+				var err error
+				if targetDoc, err = s.document(target); err != nil {
+					return err
+				}
+			}
+			if _, ok := targetDoc.Heading(string(n.Fragment)); !ok {
+				msg = fmt.Sprintf("no heading %q in %v", n.Fragment, vault.Name(target))
+				if len(n.Target) == 0 {
+					msg = fmt.Sprintf("no heading %q in this page", n.Fragment)
+				}
+			}
+		}
+
+		if msg != "" {
+			diags = append(diags, diagnostic{
+				Range:    doc.Range(n.Segment.Start, n.Segment.Stop),
+				Severity: _severityWarning,
+				Source:   "wikilink",
+				Message:  msg,
+			})
+		}
+	}
+
+	version := doc.Version
+	return s.conn.Notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+		URI:         s.uri(p),
+		Version:     &version,
+		Diagnostics: diags,
+	})
+}
+
+// openDocument returns the path and contents of a document
+// referenced in a request.
+func (s *server) openDocument(uri string) (string, *document, error) {
+	p, err := s.path(uri)
+	if err != nil {
+		return "", nil, err
+	}
+	doc, err := s.document(p)
+	if err != nil {
+		return "", nil, &rpcError{Code: _invalidParams, Message: err.Error()}
+	}
+	return p, doc, nil
+}
+
+// linkPath returns the full target for a file in wikilinks,
+// omitting the extension for pages.
+//
+//	linkPath("notes/Foo.md")  // => "notes/Foo"
+//	linkPath("img/photo.png") // => "img/photo.png"
+func linkPath(p string) string {
+	if vault.IsPage(p) {
+		return strings.TrimSuffix(p, path.Ext(p))
+	}
+	return p
+}
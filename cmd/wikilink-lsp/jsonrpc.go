@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// JSON-RPC error codes used by the server.
+const (
+	_parseError     = -32700
+	_invalidRequest = -32600
+	_methodNotFound = -32601
+	_invalidParams  = -32602
+	_internalError  = -32603
+)
+
+// message is an incoming JSON-RPC request, notification, or response.
+// Notifications don't have an ID.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+
+	// Set for responses to requests made by the server.
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%v (code %d)", e.Message, e.Code)
+}
+
+// response is a successful response to a request.
+// Result is always present, even if it's null.
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  any              `json:"result"`
+}
+
+// errorResponse is a failed response to a request.
+type errorResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   *rpcError        `json:"error"`
+}
+
+// notification is a message sent by the server
+// that does not expect a response.
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// conn reads and writes JSON-RPC messages
+// framed with LSP's Content-Length headers.
+//
+//	Content-Length: 42\r\n
+//	\r\n
+//	{"jsonrpc":"2.0", ...}
+type conn struct {
+	r *textproto.Reader
+
+	mu sync.Mutex // guards w
+	w  io.Writer
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{
+		r: textproto.NewReader(bufio.NewReader(r)),
+		w: w,
+	}
+}
+
+// Read reads the next message.
+// It returns io.EOF if the input was closed between messages.
+func (c *conn) Read() (*message, error) {
+	header, err := c.r.ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	size, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("bad Content-Length %q", header.Get("Content-Length"))
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(c.r.R, body); err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, &rpcError{Code: _parseError, Message: err.Error()}
+	}
+	return &msg, nil
+}
+
+// Write writes a message.
+// It's safe to call from multiple goroutines.
+func (c *conn) Write(msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n", len(body))
+	buf.Write(body)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = buf.WriteTo(c.w)
+	return err
+}
+
+// Reply responds to a request with a result or an error.
+func (c *conn) Reply(id *json.RawMessage, result any, err error) error {
+	if err == nil {
+		return c.Write(&response{JSONRPC: "2.0", ID: id, Result: result})
+	}
+
+	rerr, ok := err.(*rpcError)
+	if !ok {
+		rerr = &rpcError{Code: _internalError, Message: err.Error()}
+	}
+	return c.Write(&errorResponse{JSONRPC: "2.0", ID: id, Error: rerr})
+}
+
+// Notify sends a notification.
+func (c *conn) Notify(method string, params any) error {
+	return c.Write(&notification{JSONRPC: "2.0", Method: method, Params: params})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConn_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	c := newConn(&buf, &buf)
+
+	id := json.RawMessage(`1`)
+	require.NoError(t, c.Reply(&id, map[string]int{"x": 1}, nil))
+	require.NoError(t, c.Reply(&id, nil, errors.New("great sadness")))
+	require.NoError(t, c.Notify("hello", []string{"world"}))
+	assert.True(t, strings.HasPrefix(buf.String(), "Content-Length: 41\r\n\r\n{"), "got %q", buf.String())
+
+	msg, err := c.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(*msg.ID))
+	assert.JSONEq(t, `{"x": 1}`, string(msg.Result))
+
+	msg, err = c.Read()
+	require.NoError(t, err)
+	assert.Equal(t, &rpcError{Code: _internalError, Message: "great sadness"}, msg.Error)
+
+	msg, err = c.Read()
+	require.NoError(t, err)
+	assert.Nil(t, msg.ID)
+	assert.Equal(t, "hello", msg.Method)
+	assert.JSONEq(t, `["world"]`, string(msg.Params))
+
+	_, err = c.Read()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestConn_readErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		give  string
+		want  string
+		rpcEr bool
+	}{
+		{
+			desc: "missing length",
+			give: "Content-Type: foo\r\n\r\n{}",
+			want: `bad Content-Length ""`,
+		},
+		{
+			desc: "truncated body",
+			give: "Content-Length: 10\r\n\r\n{}",
+			want: "read body",
+		},
+		{
+			desc: "truncated header",
+			give: "Content-Length: 10\r\n",
+			want: "read header",
+		},
+		{
+			desc:  "bad JSON",
+			give:  "Content-Length: 2\r\n\r\n{]",
+			want:  "invalid character",
+			rpcEr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newConn(strings.NewReader(tt.give), io.Discard).Read()
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.want)
+
+			var rerr *rpcError
+			if assert.Equal(t, tt.rpcEr, errors.As(err, &rerr)) && tt.rpcEr {
+				assert.Equal(t, _parseError, rerr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// wikilink-lsp is a language server for directories of Markdown notes
+// that link to each other with wikilinks.
+//
+// It speaks the Language Server Protocol over stdin and stdout,
+// and supports:
+//
+//   - completion of page names after "[[" and headings after "[[Page#"
+//   - go to definition of the page or heading a wikilink refers to
+//   - find references to a page (backlinks)
+//   - renaming a page and updating wikilinks to it
+//   - diagnostics for wikilinks to pages or headings that don't exist
+//
+// The first workspace folder is used as the root of the vault.
+// Wikilinks are resolved the same way as Obsidian:
+// [[Foo]] refers to the closest file named Foo.md in the vault.
+//
+// Configure your editor to run "wikilink-lsp" for Markdown files.
+// Logs are written to stderr.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: wikilink-lsp")
+		fmt.Fprintln(flag.CommandLine.Output(), "Runs a wikilink language server over stdin and stdout.")
+	}
+	flag.Parse()
+
+	srv := newServer(os.Stdin, os.Stdout, os.Stderr)
+	if err := srv.Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, "wikilink-lsp:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"go.abhg.dev/goldmark/wikilink/internal/vault"
+)
+
+// _serverNotInitialized is the LSP error code for requests
+// received before the initialize request.
+const _serverNotInitialized = -32002
+
+// errExitWithoutShutdown is returned by Serve
+// if the client sends the exit notification
+// without first requesting a shutdown.
+var errExitWithoutShutdown = errors.New("exit without shutdown")
+
+// server is a language server for a vault of Markdown notes.
+//
+// Messages are handled one at a time in the order they're received
+// so document changes are always visible to the requests that follow them.
+type server struct {
+	conn *conn
+	log  io.Writer
+
+	root  string // absolute path to the vault; empty until initialized
+	vault *vault.Vault
+
+	// open holds the documents opened by the client, keyed by path.
+	// These take precedence over the contents of files on disk.
+	open     map[string]*openDocument
+	shutdown bool
+
+	// renameFiles is set if the client can rename files
+	// as part of a workspace edit.
+	renameFiles bool
+}
+
+type openDocument struct {
+	*document
+
+	Version int
+}
+
+func newServer(r io.Reader, w io.Writer, log io.Writer) *server {
+	return &server{
+		conn: newConn(r, w),
+		log:  log,
+		open: make(map[string]*openDocument),
+	}
+}
+
+// Serve handles messages until the client sends the exit notification
+// or closes the connection.
+func (s *server) Serve() error {
+	for {
+		msg, err := s.conn.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			var rerr *rpcError
+			if errors.As(err, &rerr) {
+				// The message was malformed but the stream is intact.
+				_ = s.conn.Reply(nil, nil, rerr)
+				continue
+			}
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errExitWithoutShutdown
+			}
+			return nil
+		}
+
+		if msg.ID == nil {
+			if err := s.notify(msg.Method, msg.Params); err != nil {
+				s.logf("%v: %v", msg.Method, err)
+			}
+			continue
+		}
+
+		if msg.Method == "" {
+			continue // response to a request we didn't send
+		}
+
+		result, err := s.request(msg.Method, msg.Params)
+		if err := s.conn.Reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+// request handles a request from the client.
+func (s *server) request(method string, params json.RawMessage) (any, error) {
+	switch {
+	case method == "initialize":
+		return s.initialize(params)
+	case s.root == "":
+		return nil, &rpcError{Code: _serverNotInitialized, Message: "server not initialized"}
+	case s.shutdown:
+		return nil, &rpcError{Code: _invalidRequest, Message: "server is shutting down"}
+	}
+
+	switch method {
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/completion":
+		return handle(params, s.completion)
+	case "textDocument/definition":
+		return handle(params, s.definition)
+	case "textDocument/references":
+		return handle(params, s.references)
+	case "textDocument/rename":
+		return handle(params, s.rename)
+	default:
+		return nil, &rpcError{Code: _methodNotFound, Message: "method not found: " + method}
+	}
+}
+
+// notify handles a notification from the client.
+// Unknown notifications are ignored.
+func (s *server) notify(method string, params json.RawMessage) error {
+	if s.root == "" {
+		return nil
+	}
+
+	var err error
+	switch method {
+	case "textDocument/didOpen":
+		_, err = handle(params, s.didOpen)
+	case "textDocument/didChange":
+		_, err = handle(params, s.didChange)
+	case "textDocument/didSave":
+		_, err = handle(params, s.didSave)
+	case "textDocument/didClose":
+		_, err = handle(params, s.didClose)
+	case "workspace/didChangeWatchedFiles":
+		err = s.reload()
+	}
+	return err
+}
+
+// handle decodes the parameters of a message and calls f with them.
+func handle[P any, R any](params json.RawMessage, f func(*P) (R, error)) (any, error) {
+	var p P
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: _invalidParams, Message: err.Error()}
+	}
+	return f(&p)
+}
+
+func (s *server) initialize(raw json.RawMessage) (any, error) {
+	if s.root != "" {
+		return nil, &rpcError{Code: _invalidRequest, Message: "already initialized"}
+	}
+
+	var params initializeParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &rpcError{Code: _invalidParams, Message: err.Error()}
+	}
+
+	rootURI := params.RootURI
+	if len(params.WorkspaceFolders) > 0 {
+		rootURI = params.WorkspaceFolders[0].URI
+	}
+	if rootURI == "" {
+		return nil, &rpcError{Code: _invalidParams, Message: "workspace folder is required"}
+	}
+	root, err := uriToPath(rootURI)
+	if err != nil {
+		return nil, &rpcError{Code: _invalidParams, Message: err.Error()}
+	}
+
+	edit := params.Capabilities.Workspace.WorkspaceEdit
+	s.renameFiles = edit.DocumentChanges && slices.Contains(edit.ResourceOperations, "rename")
+
+	s.root = root
+	if err := s.reload(); err != nil {
+		s.root = ""
+		return nil, err
+	}
+
+	return &initializeResult{
+		ServerInfo: serverInfo{Name: "wikilink-lsp"},
+		Capabilities: serverCapabilities{
+			TextDocumentSync: textDocumentSyncOptions{
+				OpenClose: true,
+				Change:    _syncFull,
+				Save:      saveOptions{IncludeText: true},
+			},
+			CompletionProvider: completionOptions{TriggerCharacters: []string{"[", "#"}},
+			DefinitionProvider: true,
+			ReferencesProvider: true,
+			RenameProvider:     true,
+		},
+	}, nil
+}
+
+// reload re-indexes the files in the vault.
+func (s *server) reload() error {
+	v, err := vault.Load(os.DirFS(s.root))
+	if err != nil {
+		return fmt.Errorf("load vault: %w", err)
+	}
+	s.vault = v
+	return nil
+}
+
+func (s *server) didOpen(params *didOpenTextDocumentParams) (any, error) {
+	p, err := s.path(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	s.open[p] = &openDocument{
+		document: parseDocument([]byte(params.TextDocument.Text)),
+		Version:  params.TextDocument.Version,
+	}
+
+	// The file may be new.
+	if _, ok := s.vault.Lookup(p); !ok {
+		if err := s.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, s.publishDiagnostics(p)
+}
+
+func (s *server) didChange(params *didChangeTextDocumentParams) (any, error) {
+	p, err := s.path(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := s.open[p]
+	if !ok || len(params.ContentChanges) == 0 {
+		return nil, nil
+	}
+
+	// With full sync, the last change holds the full text.
+	text := params.ContentChanges[len(params.ContentChanges)-1].Text
+	doc.document = parseDocument([]byte(text))
+	if v := params.TextDocument.Version; v != nil {
+		doc.Version = *v
+	}
+	return nil, s.publishDiagnostics(p)
+}
+
+func (s *server) didSave(params *didSaveTextDocumentParams) (any, error) {
+	p, err := s.path(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	if doc, ok := s.open[p]; ok && params.Text != nil {
+		doc.document = parseDocument([]byte(*params.Text))
+	}
+
+	// Saving may have created a new file.
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return nil, s.publishDiagnostics(p)
+}
+
+func (s *server) didClose(params *didCloseTextDocumentParams) (any, error) {
+	p, err := s.path(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.open, p)
+
+	return nil, s.conn.Notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []diagnostic{},
+	})
+}
+
+// document returns the contents of the page at the provided path.
+// Open documents take precedence over files on disk.
+func (s *server) document(p string) (*document, error) {
+	if doc, ok := s.open[p]; ok {
+		return doc.document, nil
+	}
+
+	src, err := s.vault.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(src), nil
+}
+
+// path returns the path relative to the vault
+// of the file with the provided URI.
+func (s *server) path(uri string) (string, error) {
+	abs, err := uriToPath(uri)
+	if err != nil {
+		return "", &rpcError{Code: _invalidParams, Message: err.Error()}
+	}
+
+	rel, err := filepath.Rel(s.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &rpcError{Code: _invalidParams, Message: fmt.Sprintf("%v is not inside %v", uri, s.root)}
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// uri returns the URI of the file at the provided path in the vault.
+func (s *server) uri(p string) string {
+	return pathToURI(filepath.Join(s.root, filepath.FromSlash(p)))
+}
+
+func (s *server) logf(format string, args ...any) {
+	fmt.Fprintf(s.log, format+"\n", args...)
+}
+
+// uriToPath returns the file system path for a file:// URI.
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI %q: must be a file:// URI", uri)
+	}
+
+	p := u.Path
+	if runtime.GOOS == "windows" {
+		p = strings.TrimPrefix(p, "/") // file:///C:/foo
+	}
+	return filepath.Clean(filepath.FromSlash(p)), nil
+}
+
+// pathToURI returns the file:// URI for an absolute path.
+func pathToURI(p string) string {
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // C:/foo => /C:/foo
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
@@ -0,0 +1,562 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClient is an in-process LSP client connected to a server.
+type testClient struct {
+	t    testing.TB
+	conn *conn
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[string]chan *message // by request ID
+
+	// notifications receives notifications from the server.
+	notifications chan *message
+
+	// done receives the result of Serve.
+	done chan error
+}
+
+func newTestClient(t testing.TB) *testClient {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	srv := newServer(serverIn, serverOut, io.Discard)
+	c := &testClient{
+		t:             t,
+		conn:          newConn(clientIn, clientOut),
+		pending:       make(map[string]chan *message),
+		notifications: make(chan *message, 100),
+		done:          make(chan error, 1),
+	}
+
+	go func() {
+		err := srv.Serve()
+		_ = serverOut.Close()
+		c.done <- err
+	}()
+	go c.readLoop()
+
+	t.Cleanup(func() {
+		_ = clientOut.Close()
+	})
+	return c
+}
+
+func (c *testClient) readLoop() {
+	for {
+		msg, err := c.conn.Read()
+		if err != nil {
+			return
+		}
+		if msg.ID == nil {
+			c.notifications <- msg
+			continue
+		}
+
+		c.mu.Lock()
+		ch := c.pending[string(*msg.ID)]
+		delete(c.pending, string(*msg.ID))
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- msg
+		}
+	}
+}
+
+// Call sends a request and decodes the result into result.
+func (c *testClient) Call(method string, params, result any) error {
+	c.mu.Lock()
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	ch := make(chan *message, 1)
+	c.pending[string(id)] = ch
+	c.mu.Unlock()
+
+	require.NoError(c.t, c.conn.Write(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      &id,
+		"method":  method,
+		"params":  params,
+	}))
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			require.NoError(c.t, json.Unmarshal(msg.Result, result))
+		}
+		return nil
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timed out waiting for response to %v", method)
+		return nil
+	}
+}
+
+// Notify sends a notification.
+func (c *testClient) Notify(method string, params any) {
+	require.NoError(c.t, c.conn.Notify(method, params))
+}
+
+// Diagnostics waits for the next diagnostics published by the server.
+func (c *testClient) Diagnostics() publishDiagnosticsParams {
+	for {
+		select {
+		case msg := <-c.notifications:
+			if msg.Method != "textDocument/publishDiagnostics" {
+				continue
+			}
+			var params publishDiagnosticsParams
+			require.NoError(c.t, json.Unmarshal(msg.Params, &params))
+			return params
+		case <-time.After(5 * time.Second):
+			c.t.Fatalf("timed out waiting for diagnostics")
+			return publishDiagnosticsParams{}
+		}
+	}
+}
+
+// Initialize initializes the server with the provided directory
+// as the vault and the provided client capabilities.
+func (c *testClient) Initialize(dir string, capabilities map[string]any) *initializeResult {
+	var result initializeResult
+	require.NoError(c.t, c.Call("initialize", map[string]any{
+		"rootUri":      pathToURI(dir),
+		"capabilities": capabilities,
+	}, &result))
+	c.Notify("initialized", struct{}{})
+	return &result
+}
+
+// Open opens a document with the provided text
+// and returns the diagnostics for it.
+func (c *testClient) Open(uri, text string) publishDiagnosticsParams {
+	c.Notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{
+			"uri":        uri,
+			"languageId": "markdown",
+			"version":    1,
+			"text":       text,
+		},
+	})
+	return c.Diagnostics()
+}
+
+func testVault(t testing.TB) string {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Home.md": "# Home\n\n" +
+			"See [[Foo]], [[Foo#Details]], and [[Missing]].\n" +
+			"Also [[Foo#Nope]] and [[#Home]].\n",
+		"notes/Foo.md": "# Foo\n\n## Details\n\nBack to [[Home]].\n",
+		"notes/Bar.md": "[[notes/Foo|foo]] ![[photo.png]]\n",
+		"photo.png":    "png",
+	})
+	return dir
+}
+
+func TestServer_lifecycle(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t)
+
+	err := c.Call("textDocument/completion", struct{}{}, nil)
+	var rerr *rpcError
+	require.ErrorAs(t, err, &rerr)
+	assert.Equal(t, _serverNotInitialized, rerr.Code)
+
+	result := c.Initialize(testVault(t), nil)
+	assert.Equal(t, "wikilink-lsp", result.ServerInfo.Name)
+	assert.True(t, result.Capabilities.DefinitionProvider)
+	assert.Equal(t, []string{"[", "#"}, result.Capabilities.CompletionProvider.TriggerCharacters)
+
+	err = c.Call("textDocument/hover", struct{}{}, nil)
+	require.ErrorAs(t, err, &rerr)
+	assert.Equal(t, _methodNotFound, rerr.Code)
+
+	require.NoError(t, c.Call("shutdown", nil, nil))
+	c.Notify("exit", nil)
+	assert.NoError(t, <-c.done)
+}
+
+func TestServer_exitWithoutShutdown(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t)
+	c.Initialize(testVault(t), nil)
+	c.Notify("exit", nil)
+	assert.ErrorIs(t, <-c.done, errExitWithoutShutdown)
+}
+
+func TestServer_diagnostics(t *testing.T) {
+	t.Parallel()
+
+	dir := testVault(t)
+	c := newTestClient(t)
+	c.Initialize(dir, nil)
+
+	homeURI := pathToURI(filepath.Join(dir, "Home.md"))
+	home, err := os.ReadFile(filepath.Join(dir, "Home.md"))
+	require.NoError(t, err)
+
+	diags := c.Open(homeURI, string(home))
+	assert.Equal(t, homeURI, diags.URI)
+	assert.Equal(t, []diagnostic{
+		{
+			Range:    lspRange{Start: position{2, 34}, End: position{2, 45}},
+			Severity: _severityWarning,
+			Source:   "wikilink",
+			Message:  "unresolved wikilink [[Missing]]",
+		},
+		{
+			Range:    lspRange{Start: position{3, 5}, End: position{3, 17}},
+			Severity: _severityWarning,
+			Source:   "wikilink",
+			Message:  `no heading "Nope" in Foo`,
+		},
+	}, diags.Diagnostics)
+
+	c.Notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": homeURI, "version": 2},
+		"contentChanges": []map[string]any{{"text": "[[Foo]] [[#Nowhere]]"}},
+	})
+	diags = c.Diagnostics()
+	require.NotNil(t, diags.Version)
+	assert.Equal(t, 2, *diags.Version)
+	require.Len(t, diags.Diagnostics, 1)
+	assert.Equal(t, `no heading "Nowhere" in this page`, diags.Diagnostics[0].Message)
+
+	c.Notify("textDocument/didClose", map[string]any{
+		"textDocument": map[string]any{"uri": homeURI},
+	})
+	assert.Empty(t, c.Diagnostics().Diagnostics, "diagnostics must be cleared")
+}
+
+func TestServer_completion(t *testing.T) {
+	t.Parallel()
+
+	dir := testVault(t)
+	c := newTestClient(t)
+	c.Initialize(dir, nil)
+
+	uri := pathToURI(filepath.Join(dir, "New.md"))
+	c.Open(uri, "")
+
+	tests := []struct {
+		desc string
+		text string
+		want []completionItem
+	}{
+		{
+			desc: "pages",
+			text: "See [[fo",
+			want: []completionItem{
+				{
+					Label:    "Foo",
+					Kind:     _completionFile,
+					Detail:   "notes/Foo.md",
+					TextEdit: &textEdit{Range: lspRange{position{0, 6}, position{0, 8}}, NewText: "Foo"},
+				},
+			},
+		},
+		{
+			desc: "attachments",
+			text: "![[PHO",
+			want: []completionItem{
+				{
+					Label:    "photo.png",
+					Kind:     _completionFile,
+					Detail:   "photo.png",
+					TextEdit: &textEdit{Range: lspRange{position{0, 3}, position{0, 6}}, NewText: "photo.png"},
+				},
+			},
+		},
+		{
+			desc: "headings",
+			text: "[[Foo#de",
+			want: []completionItem{
+				{
+					Label:    "Details",
+					Kind:     _completionFile,
+					TextEdit: &textEdit{Range: lspRange{position{0, 6}, position{0, 8}}, NewText: "Details"},
+				},
+			},
+		},
+		{desc: "closed", text: "[[Foo]] x", want: nil},
+		{desc: "label", text: "[[Foo|F", want: nil},
+		{desc: "not a link", text: "Foo", want: nil},
+	}
+
+	for i, tt := range tests {
+		c.Notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": uri, "version": i + 2},
+			"contentChanges": []map[string]any{{"text": tt.text}},
+		})
+		c.Diagnostics()
+
+		var got *completionList
+		require.NoError(t, c.Call("textDocument/completion", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"position":     position{0, len(tt.text)},
+		}, &got), tt.desc)
+
+		if tt.want == nil {
+			assert.Nil(t, got, tt.desc)
+		} else {
+			require.NotNil(t, got, tt.desc)
+			assert.Equal(t, tt.want, got.Items, tt.desc)
+		}
+	}
+}
+
+func TestServer_completionAmbiguous(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/Note.md": "",
+		"b/Note.md": "",
+		"Home.md":   "[[No",
+	})
+
+	c := newTestClient(t)
+	c.Initialize(dir, nil)
+
+	var got completionList
+	require.NoError(t, c.Call("textDocument/completion", map[string]any{
+		"textDocument": map[string]any{"uri": pathToURI(filepath.Join(dir, "Home.md"))},
+		"position":     position{0, 4},
+	}, &got))
+
+	var labels []string
+	for _, item := range got.Items {
+		labels = append(labels, item.Label)
+	}
+	assert.Equal(t, []string{"a/Note", "b/Note"}, labels)
+}
+
+func TestServer_definition(t *testing.T) {
+	t.Parallel()
+
+	dir := testVault(t)
+	c := newTestClient(t)
+	c.Initialize(dir, nil)
+
+	homeURI := pathToURI(filepath.Join(dir, "Home.md"))
+	fooURI := pathToURI(filepath.Join(dir, "notes", "Foo.md"))
+	tests := []struct {
+		desc string
+		pos  position
+		want []location
+	}{
+		{
+			desc: "page",
+			pos:  position{2, 6},
+			want: []location{{URI: fooURI}},
+		},
+		{
+			desc: "heading",
+			pos:  position{2, 15},
+			want: []location{{URI: fooURI, Range: lspRange{position{2, 3}, position{2, 10}}}},
+		},
+		{
+			desc: "missing heading",
+			pos:  position{3, 8},
+			want: []location{{URI: fooURI}},
+		},
+		{
+			desc: "same page",
+			pos:  position{3, 25},
+			want: []location{{URI: homeURI, Range: lspRange{position{0, 2}, position{0, 6}}}},
+		},
+		{desc: "missing page", pos: position{2, 40}},
+		{desc: "not a link", pos: position{0, 0}},
+	}
+
+	for _, tt := range tests {
+		var got []location
+		require.NoError(t, c.Call("textDocument/definition", map[string]any{
+			"textDocument": map[string]any{"uri": homeURI},
+			"position":     tt.pos,
+		}, &got), tt.desc)
+		assert.Equal(t, tt.want, got, tt.desc)
+	}
+}
+
+func TestServer_references(t *testing.T) {
+	t.Parallel()
+
+	dir := testVault(t)
+	c := newTestClient(t)
+	c.Initialize(dir, nil)
+
+	homeURI := pathToURI(filepath.Join(dir, "Home.md"))
+	fooURI := pathToURI(filepath.Join(dir, "notes", "Foo.md"))
+	barURI := pathToURI(filepath.Join(dir, "notes", "Bar.md"))
+
+	var got []location
+	require.NoError(t, c.Call("textDocument/references", map[string]any{
+		"textDocument": map[string]any{"uri": homeURI},
+		"position":     position{2, 6}, // [[Foo]]
+		"context":      map[string]any{"includeDeclaration": true},
+	}, &got))
+	assert.Equal(t, []location{
+		{URI: fooURI},
+		{URI: homeURI, Range: lspRange{position{2, 4}, position{2, 11}}},
+		{URI: homeURI, Range: lspRange{position{2, 13}, position{2, 28}}},
+		{URI: homeURI, Range: lspRange{position{3, 5}, position{3, 17}}},
+		{URI: barURI, Range: lspRange{position{0, 0}, position{0, 17}}},
+	}, got)
+
+	// Backlinks for the current page.
+	require.NoError(t, c.Call("textDocument/references", map[string]any{
+		"textDocument": map[string]any{"uri": homeURI},
+		"position":     position{0, 0},
+	}, &got))
+	assert.Equal(t, []location{
+		{URI: fooURI, Range: lspRange{position{4, 8}, position{4, 16}}},
+	}, got)
+}
+
+func TestServer_rename(t *testing.T) {
+	t.Parallel()
+
+	dir := testVault(t)
+	homeURI := pathToURI(filepath.Join(dir, "Home.md"))
+	barURI := pathToURI(filepath.Join(dir, "notes", "Bar.md"))
+	params := map[string]any{
+		"textDocument": map[string]any{"uri": homeURI},
+		"position":     position{2, 6}, // [[Foo]]
+		"newName":      "Baz",
+	}
+
+	t.Run("changes", func(t *testing.T) {
+		t.Parallel()
+
+		c := newTestClient(t)
+		c.Initialize(dir, nil)
+
+		var got workspaceEdit
+		require.NoError(t, c.Call("textDocument/rename", params, &got))
+		assert.Equal(t, map[string][]textEdit{
+			homeURI: {
+				{Range: lspRange{position{2, 6}, position{2, 9}}, NewText: "Baz"},
+				{Range: lspRange{position{2, 15}, position{2, 18}}, NewText: "Baz"},
+				{Range: lspRange{position{3, 7}, position{3, 10}}, NewText: "Baz"},
+			},
+			barURI: {
+				{Range: lspRange{position{0, 2}, position{0, 11}}, NewText: "notes/Baz"},
+			},
+		}, got.Changes)
+	})
+
+	t.Run("document changes", func(t *testing.T) {
+		t.Parallel()
+
+		c := newTestClient(t)
+		c.Initialize(dir, map[string]any{
+			"workspace": map[string]any{
+				"workspaceEdit": map[string]any{
+					"documentChanges":    true,
+					"resourceOperations": []string{"create", "rename", "delete"},
+				},
+			},
+		})
+		home, err := os.ReadFile(filepath.Join(dir, "Home.md"))
+		require.NoError(t, err)
+		c.Open(homeURI, string(home))
+
+		var got struct {
+			DocumentChanges []json.RawMessage `json:"documentChanges"`
+		}
+		require.NoError(t, c.Call("textDocument/rename", params, &got))
+		require.Len(t, got.DocumentChanges, 3)
+		assert.JSONEq(t, `{
+			"textDocument": {"uri": "`+homeURI+`", "version": 1},
+			"edits": [
+				{"range": {"start": {"line": 2, "character": 6}, "end": {"line": 2, "character": 9}}, "newText": "Baz"},
+				{"range": {"start": {"line": 2, "character": 15}, "end": {"line": 2, "character": 18}}, "newText": "Baz"},
+				{"range": {"start": {"line": 3, "character": 7}, "end": {"line": 3, "character": 10}}, "newText": "Baz"}
+			]
+		}`, string(got.DocumentChanges[0]))
+		assert.JSONEq(t, `{
+			"textDocument": {"uri": "`+barURI+`", "version": null},
+			"edits": [
+				{"range": {"start": {"line": 0, "character": 2}, "end": {"line": 0, "character": 11}}, "newText": "notes/Baz"}
+			]
+		}`, string(got.DocumentChanges[1]))
+		assert.JSONEq(t, `{
+			"kind": "rename",
+			"oldUri": "`+pathToURI(filepath.Join(dir, "notes", "Foo.md"))+`",
+			"newUri": "`+pathToURI(filepath.Join(dir, "notes", "Baz.md"))+`"
+		}`, string(got.DocumentChanges[2]))
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		t.Parallel()
+
+		c := newTestClient(t)
+		c.Initialize(dir, nil)
+
+		err := c.Call("textDocument/rename", map[string]any{
+			"textDocument": map[string]any{"uri": homeURI},
+			"position":     position{2, 6},
+			"newName":      "Bar",
+		}, nil)
+		var rerr *rpcError
+		require.True(t, errors.As(err, &rerr), "got %v", err)
+		assert.Contains(t, rerr.Message, "notes/Bar.md already exists")
+	})
+}
+
+func TestRenamedPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		from, newName string
+		want          string
+		wantErr       string
+	}{
+		{from: "a/Foo.md", newName: "Bar", want: "a/Bar.md"},
+		{from: "a/Foo.md", newName: "Bar.md", want: "a/Bar.md"},
+		{from: "a/Foo.md", newName: "b/Bar", want: "b/Bar.md"},
+		{from: "a/Foo.md", newName: "/Bar/", want: "a/Bar.md"},
+		{from: "img.png", newName: "photo.jpg", want: "photo.jpg"},
+		{from: "a/Foo.md", newName: " ", wantErr: "must not be empty"},
+		{from: "a/Foo.md", newName: "Foo#Bar", wantErr: "must not contain"},
+		{from: "a/Foo.md", newName: "../Foo", wantErr: "must not contain"},
+	}
+
+	for _, tt := range tests {
+		got, err := renamedPath(tt.from, tt.newName)
+		if tt.wantErr != "" {
+			assert.ErrorContains(t, err, tt.wantErr, "renamedPath(%q, %q)", tt.from, tt.newName)
+		} else if assert.NoError(t, err) {
+			assert.Equal(t, tt.want, got, "renamedPath(%q, %q)", tt.from, tt.newName)
+		}
+	}
+}
+
+func writeFiles(t testing.TB, dir string, files map[string]string) {
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(body), 0o644))
+	}
+}
@@ -0,0 +1,123 @@
+// wikiserve serves a directory of Markdown notes
+// that link to each other with wikilinks over HTTP
+// for previewing them locally.
+//
+// Usage:
+//
+//	wikiserve [-addr ADDR] [-poll DURATION] VAULT
+//
+// Pages are rendered when they are requested,
+// so changes to notes are visible on the next page load.
+// wikiserve also watches the vault for changes
+// and reloads open pages in the browser when a file changes.
+//
+// Page "notes/Foo.md" is served at "/notes/Foo",
+// and attachments are served at their path in the vault.
+// Wikilinks are resolved the same way as Obsidian:
+// [[Foo]] links to the closest file named Foo.md in the vault.
+//
+// wikiserve does not need network access beyond the local server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	cmd := mainCmd{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.Run(ctx, os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "wikiserve:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+type mainCmd struct {
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// ready, if set, is called with the address of the server
+	// once it's listening.
+	ready func(addr string)
+}
+
+func (cmd *mainCmd) Run(ctx context.Context, args []string) error {
+	flag := flag.NewFlagSet("wikiserve", flag.ContinueOnError)
+	flag.SetOutput(cmd.Stderr)
+	flag.Usage = func() {
+		fmt.Fprintln(flag.Output(), "usage: wikiserve [options] VAULT")
+		flag.PrintDefaults()
+	}
+
+	addr := flag.String("addr", "localhost:8080", "listen on `ADDR`")
+	poll := flag.Duration("poll", 500*time.Millisecond, "check for changes to files every `DURATION`")
+	if err := flag.Parse(args); err != nil {
+		return err
+	}
+	if flag.NArg() != 1 {
+		flag.Usage()
+		return errors.New("expected exactly one VAULT argument")
+	}
+	dir := flag.Arg(0)
+
+	srv, err := newServer(dir, cmd.Stderr)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.Stdout, "serving %v at http://%v\n", dir, ln.Addr())
+	if cmd.ready != nil {
+		cmd.ready(ln.Addr().String())
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := watcher{Dir: dir, Interval: *poll}
+	go func() {
+		err := w.Watch(ctx, func() {
+			if err := srv.Reload(); err != nil {
+				fmt.Fprintln(cmd.Stderr, "reload:", err)
+			}
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			fmt.Fprintln(cmd.Stderr, "watch:", err)
+		}
+	}()
+
+	httpServer := &http.Server{
+		Handler:           srv,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		_ = httpServer.Close()
+	}()
+
+	if err := httpServer.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Home.md": "[[Home]]",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	addrc := make(chan string, 1)
+	var stdout, stderr bytes.Buffer
+	cmd := mainCmd{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		ready:  func(addr string) { addrc <- addr },
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Run(ctx, []string{"-addr", "localhost:0", "-poll", "10ms", dir})
+	}()
+
+	var addr string
+	select {
+	case addr = <-addrc:
+	case err := <-done:
+		t.Fatalf("server exited early: %v", err)
+	}
+
+	res, err := http.Get("http://" + addr + "/Home")
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Contains(t, string(body), `<a href="/Home">Home</a>`)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Contains(t, stdout.String(), "serving "+dir+" at http://"+addr)
+	assert.Empty(t, stderr.String())
+}
+
+func TestRun_usage(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := mainCmd{Stdout: &stdout, Stderr: &stderr}
+	err := cmd.Run(context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected exactly one VAULT argument")
+	assert.Contains(t, stderr.String(), "usage: wikiserve")
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"go.abhg.dev/goldmark/wikilink"
+	"go.abhg.dev/goldmark/wikilink/internal/vault"
+)
+
+// _reloadPath is the path of the server-sent events stream
+// that tells pages to reload.
+const _reloadPath = "/_wikiserve/reload"
+
+// server serves the pages and attachments of a vault.
+type server struct {
+	dir string
+	log io.Writer
+
+	// state holds the index of the vault.
+	// It's replaced when files in the vault change.
+	state atomic.Pointer[state]
+
+	reloads broker
+}
+
+// state is an index of the vault at a point in time.
+type state struct {
+	vault  *vault.Vault
+	md     goldmark.Markdown
+	routes map[string]string // route => page path
+}
+
+func newServer(dir string, log io.Writer) (*server, error) {
+	s := &server{dir: dir, log: log}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-indexes the vault
+// and tells all open pages to reload.
+func (s *server) Reload() error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.reloads.Publish()
+	return nil
+}
+
+func (s *server) load() error {
+	v, err := vault.Load(os.DirFS(s.dir))
+	if err != nil {
+		return fmt.Errorf("load vault: %w", err)
+	}
+
+	routes := make(map[string]string, len(v.Pages()))
+	for _, p := range v.Pages() {
+		routes[pageRoute(p)] = p
+	}
+
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			&wikilink.Extender{
+				Resolver: &vault.Resolver{
+					Vault: v,
+					URL: func(_, to string) string {
+						return pageRoute(to)
+					},
+				},
+				Figures:     true,
+				ErrorPolicy: wikilink.CollectErrors,
+			},
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+
+	s.state.Store(&state{
+		vault:  v,
+		md:     md,
+		routes: routes,
+	})
+	return nil
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.Path == _reloadPath {
+		s.serveReloads(w, r)
+		return
+	}
+
+	st := s.state.Load()
+	route := path.Clean(r.URL.Path)
+	if p, ok := st.routes[route]; ok {
+		s.servePage(w, r, st, p)
+		return
+	}
+
+	if route == "/" {
+		if p, ok := st.routes["/index"]; ok {
+			s.servePage(w, r, st, p)
+		} else {
+			s.serveIndex(w, st)
+		}
+		return
+	}
+
+	p := strings.TrimPrefix(route, "/")
+	if !vault.IsPage(p) && isAttachment(st.vault, p) {
+		s.serveAttachment(w, r, st.vault, p)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+func (s *server) servePage(w http.ResponseWriter, r *http.Request, st *state, p string) {
+	src, err := st.vault.ReadFile(p)
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+
+	pc := parser.NewContext()
+	wikilink.SetContext(pc, vault.WithPage(r.Context(), p))
+	var diags wikilink.Diagnostics
+	wikilink.RecordDiagnostics(pc, &diags)
+
+	var body bytes.Buffer
+	if err := st.md.Convert(src, &body, parser.WithContext(pc)); err != nil {
+		s.error(w, fmt.Errorf("%v: %w", p, err))
+		return
+	}
+
+	warnings := make([]string, 0, diags.Len())
+	for _, d := range diags.List() {
+		warnings = append(warnings, d.String())
+	}
+
+	s.render(w, "page", pageData{
+		Title:      vault.Name(p),
+		Path:       p,
+		Content:    template.HTML(body.String()), // rendered by goldmark
+		Warnings:   warnings,
+		ReloadPath: _reloadPath,
+	})
+}
+
+func (s *server) serveIndex(w http.ResponseWriter, st *state) {
+	pages := make([]linkData, 0, len(st.vault.Pages()))
+	for _, p := range st.vault.Pages() {
+		pages = append(pages, linkData{
+			Title: p,
+			URL:   pageRoute(p),
+		})
+	}
+
+	s.render(w, "index", indexData{
+		Pages:      pages,
+		ReloadPath: _reloadPath,
+	})
+}
+
+func (s *server) serveAttachment(w http.ResponseWriter, r *http.Request, v *vault.Vault, p string) {
+	f, err := v.Open(p)
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		s.error(w, fmt.Errorf("%v: file is not seekable", p))
+		return
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), rs)
+}
+
+// serveReloads streams a "reload" server-sent event
+// every time the vault changes.
+func (s *server) serveReloads(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	reloads, unsubscribe := s.reloads.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-reloads:
+			_, _ = io.WriteString(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *server) render(w http.ResponseWriter, name string, data any) {
+	var buf bytes.Buffer
+	if err := _templates.ExecuteTemplate(&buf, name, data); err != nil {
+		s.error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+func (s *server) error(w http.ResponseWriter, err error) {
+	fmt.Fprintln(s.log, "error:", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// pageRoute returns the URL path that the file at the provided path
+// is served at.
+//
+//	pageRoute("notes/Foo.md")     // => "/notes/Foo"
+//	pageRoute("images/photo.png") // => "/images/photo.png"
+func pageRoute(p string) string {
+	if vault.IsPage(p) {
+		p = strings.TrimSuffix(p, path.Ext(p))
+	}
+	return "/" + p
+}
+
+// isAttachment reports whether p is the path of an attachment in the vault.
+func isAttachment(v *vault.Vault, p string) bool {
+	attachments := v.Attachments()
+	i := sort.SearchStrings(attachments, p)
+	return i < len(attachments) && attachments[i] == p
+}
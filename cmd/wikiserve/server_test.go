@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Home.md":          "See [[Foo Bar]], [[Foo Bar#Some Heading|heading]], and [[Missing]].\n",
+		"notes/Foo Bar.md": "## Some Heading\n\n![[photo.png]]\n",
+		"images/photo.png": "png",
+		".secret/key.txt":  "secret",
+	})
+
+	srv, err := newServer(dir, io.Discard)
+	require.NoError(t, err)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		desc       string
+		path       string
+		wantStatus int
+		want       []string
+	}{
+		{
+			desc:       "index",
+			path:       "/",
+			wantStatus: http.StatusOK,
+			want: []string{
+				`<a href="/Home">Home.md</a>`,
+				`<a href="/notes/Foo%20Bar">notes/Foo Bar.md</a>`,
+				`new EventSource("/_wikiserve/reload")`,
+			},
+		},
+		{
+			desc:       "page",
+			path:       "/Home",
+			wantStatus: http.StatusOK,
+			want: []string{
+				"<title>Home</title>",
+				`<a href="/notes/Foo%20Bar">Foo Bar</a>`,
+				`<a href="/notes/Foo%20Bar#some-heading">heading</a>`,
+				"<li>1:56: unresolved wikilink [[Missing]]</li>",
+				`new EventSource("/_wikiserve/reload")`,
+			},
+		},
+		{
+			desc:       "page in directory",
+			path:       "/notes/Foo%20Bar",
+			wantStatus: http.StatusOK,
+			want: []string{
+				`<h2 id="some-heading">Some Heading</h2>`,
+				`<img src="/images/photo.png">`,
+			},
+		},
+		{
+			desc:       "attachment",
+			path:       "/images/photo.png",
+			wantStatus: http.StatusOK,
+			want:       []string{"png"},
+		},
+		{desc: "source", path: "/Home.md", wantStatus: http.StatusNotFound},
+		{desc: "hidden", path: "/.secret/key.txt", wantStatus: http.StatusNotFound},
+		{desc: "missing", path: "/Missing", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := http.Get(ts.URL + tt.path)
+			require.NoError(t, err)
+			defer func() { _ = res.Body.Close() }()
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode, "status")
+			for _, want := range tt.want {
+				assert.Contains(t, string(body), want)
+			}
+		})
+	}
+}
+
+func TestServer_indexPage(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"index.md": "Hello",
+	})
+
+	srv, err := newServer(dir, io.Discard)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<p>Hello</p>")
+}
+
+func TestServer_reload(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Home.md": "[[Foo]]",
+	})
+
+	srv, err := newServer(dir, io.Discard)
+	require.NoError(t, err)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+_reloadPath, nil)
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	events := bufio.NewReader(res.Body)
+	line, err := events.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": connected\n", line, "must be subscribed")
+
+	writeFiles(t, dir, map[string]string{
+		"Foo.md": "Foo",
+	})
+	require.NoError(t, srv.Reload())
+
+	line, err = events.ReadString('\n')
+	require.NoError(t, err)
+	for line == "\n" {
+		line, err = events.ReadString('\n')
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "event: reload\n", line)
+
+	// The new page must be picked up.
+	page, err := http.Get(ts.URL + "/Home")
+	require.NoError(t, err)
+	defer func() { _ = page.Body.Close() }()
+	body, err := io.ReadAll(page.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `<a href="/Foo">Foo</a>`)
+}
+
+func TestBroker(t *testing.T) {
+	t.Parallel()
+
+	var b broker
+	ch1, unsubscribe1 := b.Subscribe()
+	ch2, unsubscribe2 := b.Subscribe()
+	defer unsubscribe2()
+
+	b.Publish()
+	b.Publish() // coalesced
+	assert.Len(t, ch1, 1)
+	assert.Len(t, ch2, 1)
+	<-ch1
+
+	unsubscribe1()
+	b.Publish()
+	assert.Empty(t, ch1, "unsubscribed")
+}
+
+func TestPageRoute(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "/Foo", pageRoute("Foo.md"))
+	assert.Equal(t, "/a/Foo Bar", pageRoute("a/Foo Bar.markdown"))
+	assert.Equal(t, "/a/b.png", pageRoute("a/b.png"))
+}
+
+func writeFiles(t testing.TB, dir string, files map[string]string) {
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(body), 0o644))
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import "html/template"
+
+// pageData is the data for the "page" template.
+type pageData struct {
+	Title      string
+	Path       string // path of the page in the vault
+	Content    template.HTML
+	Warnings   []string
+	ReloadPath string
+}
+
+// indexData is the data for the "index" template.
+type indexData struct {
+	Pages      []linkData
+	ReloadPath string
+}
+
+type linkData struct {
+	Title string
+	URL   string
+}
+
+// The "reload" template connects to the server-sent events stream
+// and reloads the page when the server says so.
+// EventSource reconnects on its own if the server restarts.
+var _templates = template.Must(template.New("").Parse(`
+{{- define "head" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ . }}</title>
+<style>
+body { max-width: 48rem; margin: 0 auto; padding: 1rem; font-family: sans-serif; line-height: 1.5; }
+header { border-bottom: 1px solid #ddd; margin-bottom: 1rem; }
+.warnings { background: #fff3cd; padding: 0.5rem 1rem; }
+img { max-width: 100%; }
+figure { margin: 1rem 0; }
+figcaption { color: #666; font-size: 0.9em; }
+</style>
+</head>
+{{- end -}}
+
+{{- define "reload" -}}
+<script>
+new EventSource({{ . }}).addEventListener("reload", () => location.reload());
+</script>
+{{- end -}}
+
+{{- define "page" -}}
+{{ template "head" .Title }}
+<body>
+<header><a href="/">Index</a> / {{ .Path }}</header>
+{{- with .Warnings }}
+<ul class="warnings">
+{{- range . }}
+<li>{{ . }}</li>
+{{- end }}
+</ul>
+{{- end }}
+<main>
+{{ .Content }}
+</main>
+{{ template "reload" .ReloadPath }}
+</body>
+</html>
+{{ end -}}
+
+{{- define "index" -}}
+{{ template "head" "Index" }}
+<body>
+<main>
+<h1>Index</h1>
+<ul>
+{{- range .Pages }}
+<li><a href="{{ .URL }}">{{ .Title }}</a></li>
+{{- end }}
+</ul>
+</main>
+{{ template "reload" .ReloadPath }}
+</body>
+</html>
+{{ end -}}
+`))
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// broker delivers notifications to all its subscribers.
+type broker struct {
+	mu   sync.Mutex
+	subs map[chan struct{}]struct{}
+}
+
+// Subscribe returns a channel that receives a value for every Publish
+// until unsubscribe is called.
+// Notifications are coalesced if the subscriber is not ready for them.
+func (b *broker) Subscribe() (_ <-chan struct{}, unsubscribe func()) {
+	ch := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[chan struct{}]struct{})
+	}
+	b.subs[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, ch)
+	}
+}
+
+// Publish notifies all subscribers.
+func (b *broker) Publish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// watcher polls a directory for changes to the files in it.
+type watcher struct {
+	Dir      string
+	Interval time.Duration
+}
+
+// Watch calls onChange every time a file in the directory
+// is added, removed, or modified
+// until the context is canceled.
+func (w *watcher) Watch(ctx context.Context, onChange func()) error {
+	last, err := w.snapshot()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		// Files may be removed while we're walking the directory.
+		// Try again on the next tick if that happens.
+		snap, err := w.snapshot()
+		if err == nil && !snap.Equal(last) {
+			last = snap
+			onChange()
+		}
+	}
+}
+
+// snapshot records the size and modification time of each file.
+type snapshot map[string]fileState
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+func (s snapshot) Equal(other snapshot) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for p, st := range s {
+		o, ok := other[p]
+		if !ok || o.size != st.size || !o.modTime.Equal(st.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *watcher) snapshot() (snapshot, error) {
+	snap := make(snapshot)
+	err := fs.WalkDir(os.DirFS(w.Dir), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		snap[p] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return snap, err
+}
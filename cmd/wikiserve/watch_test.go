@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Home.md": "Hello",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 1)
+	w := watcher{Dir: dir, Interval: 10 * time.Millisecond}
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Watch(ctx, func() { changes <- struct{}{} })
+	}()
+
+	// Wait for the initial snapshot before making changes.
+	time.Sleep(50 * time.Millisecond)
+
+	steps := []struct {
+		desc   string
+		change func()
+	}{
+		{
+			desc: "add",
+			change: func() {
+				writeFiles(t, dir, map[string]string{"notes/Foo.md": "Foo"})
+			},
+		},
+		{
+			desc: "modify",
+			change: func() {
+				writeFiles(t, dir, map[string]string{"Home.md": "Hello, world"})
+			},
+		},
+		{
+			desc: "remove",
+			change: func() {
+				require.NoError(t, os.Remove(filepath.Join(dir, "notes", "Foo.md")))
+			},
+		},
+	}
+	for _, step := range steps {
+		step.change()
+		select {
+		case <-changes:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%v: change not detected", step.desc)
+		}
+	}
+
+	// Hidden files are ignored.
+	writeFiles(t, dir, map[string]string{".obsidian/workspace.json": "{}"})
+	select {
+	case <-changes:
+		t.Errorf("hidden file change must be ignored")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"go.abhg.dev/goldmark/wikilink"
+	"go.abhg.dev/goldmark/wikilink/internal/vault"
+)
+
+// builder builds a static site from a vault.
+type builder struct {
+	Src   string    // vault directory
+	Dst   string    // output directory
+	Title string    // site title
+	Log   io.Writer // destination for warnings
+
+	numPages       int
+	numAttachments int
+}
+
+// page is a parsed page of the vault.
+type page struct {
+	Path  string
+	Src   []byte
+	Doc   ast.Node
+	Diags *wikilink.Diagnostics
+
+	// Backlinks lists the pages that link to this page, sorted.
+	Backlinks []string
+}
+
+func (b *builder) Build() error {
+	title := b.Title
+	if title == "" {
+		abs, err := filepath.Abs(b.Src)
+		if err != nil {
+			return err
+		}
+		title = filepath.Base(abs)
+	}
+
+	fsys, err := b.vaultFS()
+	if err != nil {
+		return err
+	}
+	v, err := vault.Load(fsys)
+	if err != nil {
+		return fmt.Errorf("load vault: %w", err)
+	}
+
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			&wikilink.Extender{
+				Resolver: &vault.Resolver{
+					Vault: v,
+					URL:   relativeURL,
+				},
+				Figures:     true,
+				ErrorPolicy: wikilink.CollectErrors,
+			},
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+
+	// Parse all pages first to find backlinks and attachments
+	// before rendering anything.
+	var (
+		pages       []*page
+		byPath      = make(map[string]*page)
+		attachments []string
+		seen        = make(map[string]struct{}) // attachments
+	)
+	for _, p := range v.Pages() {
+		src, err := v.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		pc := parser.NewContext()
+		wikilink.SetContext(pc, vault.WithPage(context.Background(), p))
+		diags := new(wikilink.Diagnostics)
+		wikilink.RecordDiagnostics(pc, diags)
+
+		pg := &page{
+			Path:  p,
+			Src:   src,
+			Doc:   md.Parser().Parse(text.NewReader(src), parser.WithContext(pc)),
+			Diags: diags,
+		}
+		pages = append(pages, pg)
+		byPath[p] = pg
+	}
+
+	for _, pg := range pages {
+		for _, to := range links(v, pg.Doc) {
+			if to == pg.Path {
+				continue
+			}
+
+			if target, ok := byPath[to]; ok {
+				target.Backlinks = append(target.Backlinks, pg.Path)
+			} else if _, ok := seen[to]; !ok {
+				seen[to] = struct{}{}
+				attachments = append(attachments, to)
+			}
+		}
+	}
+
+	hasIndex := false
+	for _, pg := range pages {
+		if err := b.writePage(md, title, pg); err != nil {
+			return fmt.Errorf("%v: %w", pg.Path, err)
+		}
+		hasIndex = hasIndex || htmlPath(pg.Path) == "index.html"
+
+		for _, d := range pg.Diags.List() {
+			fmt.Fprintf(b.Log, "warning: %v:%v\n", pg.Path, &d)
+		}
+	}
+	if !hasIndex {
+		if err := b.writeIndex(title, pages); err != nil {
+			return fmt.Errorf("index: %w", err)
+		}
+	}
+
+	for _, p := range attachments {
+		if err := b.copyAttachment(v, p); err != nil {
+			return fmt.Errorf("%v: %w", p, err)
+		}
+	}
+
+	b.numPages = len(pages)
+	b.numAttachments = len(attachments)
+	return nil
+}
+
+// vaultFS returns the file system holding the vault.
+// If the output directory is inside the vault, it's hidden
+// so that files from earlier builds aren't indexed as notes.
+func (b *builder) vaultFS() (fs.FS, error) {
+	src, err := filepath.Abs(b.Src)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := filepath.Abs(b.Dst)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys := os.DirFS(b.Src)
+	rel, err := filepath.Rel(src, dst)
+	switch {
+	case err != nil, rel == "..", strings.HasPrefix(rel, ".."+string(filepath.Separator)):
+		return fsys, nil // outside the vault
+	case rel == ".":
+		return nil, errors.New("output directory must not be the vault directory")
+	}
+	return &excludeFS{FS: fsys, dir: filepath.ToSlash(rel)}, nil
+}
+
+// excludeFS is a file system that hides a directory
+// of another file system.
+type excludeFS struct {
+	fs.FS
+
+	dir string // slash-separated
+}
+
+var _ fs.ReadDirFS = (*excludeFS)(nil)
+
+func (f *excludeFS) Open(name string) (fs.File, error) {
+	if f.excludes(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.FS.Open(name)
+}
+
+func (f *excludeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if f.excludes(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries, err := fs.ReadDir(f.FS, name)
+	return slices.DeleteFunc(entries, func(e fs.DirEntry) bool {
+		return f.excludes(path.Join(name, e.Name()))
+	}), err
+}
+
+func (f *excludeFS) excludes(name string) bool {
+	return name == f.dir || strings.HasPrefix(name, f.dir+"/")
+}
+
+func (b *builder) writePage(md goldmark.Markdown, site string, pg *page) error {
+	var body bytes.Buffer
+	if err := md.Renderer().Render(&body, pg.Src, pg.Doc); err != nil {
+		return err
+	}
+
+	backlinks := make([]linkData, len(pg.Backlinks))
+	for i, from := range pg.Backlinks {
+		backlinks[i] = linkData{
+			Title: vault.Name(from),
+			URL:   relativeURL(pg.Path, from),
+		}
+	}
+
+	return b.writeTemplate(htmlPath(pg.Path), "page", pageData{
+		Site:      site,
+		Title:     vault.Name(pg.Path),
+		Root:      rootURL(pg.Path),
+		Content:   template.HTML(body.String()), // rendered by goldmark
+		Backlinks: backlinks,
+	})
+}
+
+func (b *builder) writeIndex(site string, pages []*page) error {
+	links := make([]linkData, len(pages))
+	for i, pg := range pages {
+		links[i] = linkData{
+			Title: pg.Path,
+			URL:   htmlPath(pg.Path),
+		}
+	}
+
+	return b.writeTemplate("index.html", "index", indexData{
+		Site:  site,
+		Pages: links,
+	})
+}
+
+func (b *builder) writeTemplate(p, name string, data any) error {
+	var buf bytes.Buffer
+	if err := _templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+	return b.writeFile(p, func(w io.Writer) error {
+		_, err := buf.WriteTo(w)
+		return err
+	})
+}
+
+func (b *builder) copyAttachment(v *vault.Vault, p string) error {
+	src, err := v.Open(p)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	return b.writeFile(p, func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	})
+}
+
+// writeFile writes a file at the provided slash-separated path
+// inside the output directory.
+func (b *builder) writeFile(p string, write func(io.Writer) error) (err error) {
+	dst := filepath.Join(b.Dst, filepath.FromSlash(p))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	return write(f)
+}
+
+// links returns the paths of the files in the vault
+// that the wikilinks in the document refer to.
+// Each path is reported once.
+func links(v *vault.Vault, doc ast.Node) []string {
+	var (
+		paths []string
+		seen  = make(map[string]struct{})
+	)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		link, ok := n.(*wikilink.Node)
+		if !ok || !entering || len(link.Target) == 0 {
+			return ast.WalkContinue, nil
+		}
+
+		if p, ok := v.Lookup(string(link.Target)); ok {
+			if _, dup := seen[p]; !dup {
+				seen[p] = struct{}{}
+				paths = append(paths, p)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return paths
+}
+
+// htmlPath returns the path of the HTML file
+// generated for the file at the provided path.
+// Only pages are turned into HTML files.
+func htmlPath(p string) string {
+	if vault.IsPage(p) {
+		return strings.TrimSuffix(p, path.Ext(p)) + ".html"
+	}
+	return p
+}
+
+// rootURL returns the relative URL of the root of the site
+// from the page at the provided path.
+//
+//	rootURL("Foo.md")     // => ""
+//	rootURL("a/b/Foo.md") // => "../../"
+func rootURL(from string) string {
+	return strings.Repeat("../", strings.Count(from, "/"))
+}
+
+// relativeURL returns the relative URL of the output file
+// for the file at path "to" from the page at path "from".
+//
+//	relativeURL("a/Foo.md", "a/Bar.md")  // => "Bar.html"
+//	relativeURL("a/Foo.md", "b/img.png") // => "../b/img.png"
+func relativeURL(from, to string) string {
+	to = htmlPath(to)
+
+	dir := path.Dir(from)
+	if dir == "." {
+		return to
+	}
+
+	fromParts := strings.Split(dir, "/")
+	toParts := strings.Split(to, "/")
+
+	var i int
+	for i < len(fromParts) && i < len(toParts)-1 && fromParts[i] == toParts[i] {
+		i++
+	}
+	return strings.Repeat("../", len(fromParts)-i) + strings.Join(toParts[i:], "/")
+}
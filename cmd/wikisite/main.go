@@ -0,0 +1,78 @@
+// wikisite builds a static HTML site from a directory of Markdown notes
+// that link to each other with wikilinks.
+//
+// Usage:
+//
+//	wikisite [-o DIR] [-title TITLE] VAULT
+//
+// Every Markdown file in VAULT is rendered to an HTML file
+// at the same path in the output directory,
+// with a list of the pages that link to it.
+// Attachments referenced by wikilinks, like images embedded with ![[...]],
+// are copied to the output directory.
+// Hidden files and directories are ignored,
+// as is the output directory if it's inside VAULT.
+//
+// Wikilinks are resolved the same way as Obsidian:
+// [[Foo]] links to the closest file named Foo.md in the vault.
+// Wikilinks that cannot be resolved are reported as warnings
+// and rendered as plain text.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	cmd := mainCmd{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	if err := cmd.Run(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "wikisite:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+type mainCmd struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (cmd *mainCmd) Run(args []string) error {
+	flag := flag.NewFlagSet("wikisite", flag.ContinueOnError)
+	flag.SetOutput(cmd.Stderr)
+	flag.Usage = func() {
+		fmt.Fprintln(flag.Output(), "usage: wikisite [options] VAULT")
+		flag.PrintDefaults()
+	}
+
+	out := flag.String("o", "_site", "write the site to `DIR`")
+	title := flag.String("title", "", "`TITLE` of the site (default: name of VAULT)")
+	if err := flag.Parse(args); err != nil {
+		return err
+	}
+	if flag.NArg() != 1 {
+		flag.Usage()
+		return errors.New("expected exactly one VAULT argument")
+	}
+
+	b := builder{
+		Src:   flag.Arg(0),
+		Dst:   *out,
+		Title: *title,
+		Log:   cmd.Stderr,
+	}
+	if err := b.Build(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.Stdout, "wrote %d pages and %d attachments to %v\n", b.numPages, b.numAttachments, b.Dst)
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"Home.md": "Welcome! See [[Foo]] and [[notes/Bar#Some Heading|bar]].\n\n" +
+			"[[Missing]]\n",
+		"notes/Foo.md":     "![[photo.png|A photo]]\n\nBack [[Home]].\n",
+		"notes/Bar.md":     "## Some Heading\n\nSee [[Foo]] and [[#Some Heading]].\n",
+		"images/photo.png": "png",
+		"unused.png":       "unused",
+		".obsidian/a.json": "{}",
+	})
+
+	dst := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	cmd := mainCmd{Stdout: &stdout, Stderr: &stderr}
+	require.NoError(t, cmd.Run([]string{"-o", dst, "-title", "My Notes", src}))
+
+	assert.Equal(t, "wrote 3 pages and 1 attachments to "+dst+"\n", stdout.String())
+	assert.Equal(t, "warning: Home.md:3:1: unresolved wikilink [[Missing]]\n", stderr.String())
+
+	home := readFile(t, dst, "Home.html")
+	assert.Contains(t, home, "<title>Home - My Notes</title>")
+	assert.Contains(t, home, `<a href="notes/Foo.html">Foo</a>`)
+	assert.Contains(t, home, `<a href="notes/Bar.html#some-heading">bar</a>`)
+	assert.Contains(t, home, "<p>Missing</p>")
+	assert.Contains(t, home, `<li><a href="notes/Foo.html">Foo</a></li>`, "backlinks")
+
+	foo := readFile(t, dst, "notes/Foo.html")
+	assert.Contains(t, foo, `<a href="../index.html">My Notes</a>`)
+	assert.Contains(t, foo, `<img src="../images/photo.png" alt="A photo">`)
+	assert.Contains(t, foo, `<a href="../Home.html">Home</a>`)
+	assert.Contains(t, foo, "<h2>Backlinks</h2>")
+	assert.Contains(t, foo, `<li><a href="../Home.html">Home</a></li>`)
+	assert.Contains(t, foo, `<li><a href="Bar.html">Bar</a></li>`)
+
+	bar := readFile(t, dst, "notes/Bar.html")
+	assert.Contains(t, bar, `<h2 id="some-heading">Some Heading</h2>`)
+	assert.Contains(t, bar, `<a href="#some-heading">#Some Heading</a>`)
+
+	index := readFile(t, dst, "index.html")
+	assert.Contains(t, index, `<li><a href="notes/Foo.html">notes/Foo.md</a></li>`)
+
+	assert.Equal(t, "png", readFile(t, dst, "images/photo.png"))
+	assert.NoFileExists(t, filepath.Join(dst, "unused.png"))
+	assert.NoDirExists(t, filepath.Join(dst, ".obsidian"))
+}
+
+func TestRun_index(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"index.md": "Hello",
+	})
+
+	dst := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	cmd := mainCmd{Stdout: &stdout, Stderr: &stderr}
+	require.NoError(t, cmd.Run([]string{"-o", dst, src}))
+
+	index := readFile(t, dst, "index.html")
+	assert.Contains(t, index, "<p>Hello</p>", "index page must not be replaced")
+	assert.Contains(t, index, "<title>index - "+filepath.Base(src)+"</title>")
+}
+
+func TestRun_outputInVault(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"Home.md": "See [[Home.html]].\n",
+	})
+	dst := filepath.Join(src, "_site")
+
+	// Build twice so that the second build sees the first's output.
+	for range 2 {
+		var stdout, stderr bytes.Buffer
+		cmd := mainCmd{Stdout: &stdout, Stderr: &stderr}
+		require.NoError(t, cmd.Run([]string{"-o", dst, src}))
+
+		assert.Equal(t, "wrote 1 pages and 0 attachments to "+dst+"\n", stdout.String())
+		assert.Equal(t, "warning: Home.md:1:5: unresolved wikilink [[Home.html]]\n", stderr.String())
+	}
+	assert.NoDirExists(t, filepath.Join(dst, "_site"))
+}
+
+func TestRun_outputIsVault(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{"Home.md": "Hello"})
+
+	var stdout, stderr bytes.Buffer
+	cmd := mainCmd{Stdout: &stdout, Stderr: &stderr}
+	err := cmd.Run([]string{"-o", src, src})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must not be the vault")
+	assert.NoFileExists(t, filepath.Join(src, "Home.html"))
+}
+
+func TestRun_usage(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := mainCmd{Stdout: &stdout, Stderr: &stderr}
+	err := cmd.Run(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected exactly one VAULT argument")
+	assert.Contains(t, stderr.String(), "usage: wikisite")
+}
+
+func TestRelativeURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"Foo.md", "Bar.md", "Bar.html"},
+		{"Foo.md", "a/Bar.md", "a/Bar.html"},
+		{"a/Foo.md", "Bar.md", "../Bar.html"},
+		{"a/Foo.md", "a/Bar.md", "Bar.html"},
+		{"a/Foo.md", "a/b/Bar.md", "b/Bar.html"},
+		{"a/b/Foo.md", "a/c/img.png", "../c/img.png"},
+		{"a/b/Foo.md", "a/b.md", "../b.html"},
+		{"a/Foo.md", "a/Foo.md", "Foo.html"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, relativeURL(tt.from, tt.to), "relativeURL(%q, %q)", tt.from, tt.to)
+	}
+}
+
+func writeFiles(t testing.TB, dir string, files map[string]string) {
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(body), 0o644))
+	}
+}
+
+func readFile(t testing.TB, dir, name string) string {
+	body, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	require.NoError(t, err)
+	return string(body)
+}
@@ -0,0 +1,81 @@
+package main
+
+import "html/template"
+
+// pageData is the data for the "page" template.
+type pageData struct {
+	Site      string
+	Title     string
+	Root      string // relative URL of the site root, ending in "/"
+	Content   template.HTML
+	Backlinks []linkData
+}
+
+// indexData is the data for the "index" template.
+type indexData struct {
+	Site  string
+	Pages []linkData
+}
+
+type linkData struct {
+	Title string
+	URL   string
+}
+
+var _templates = template.Must(template.New("").Parse(`
+{{- define "head" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ . }}</title>
+<style>
+body { max-width: 48rem; margin: 0 auto; padding: 1rem; font-family: sans-serif; line-height: 1.5; }
+header { border-bottom: 1px solid #ddd; margin-bottom: 1rem; }
+aside { border-top: 1px solid #ddd; margin-top: 2rem; }
+img { max-width: 100%; }
+figure { margin: 1rem 0; }
+figcaption { color: #666; font-size: 0.9em; }
+</style>
+</head>
+{{- end -}}
+
+{{- define "page" -}}
+{{ template "head" (printf "%v - %v" .Title .Site) }}
+<body>
+<header><a href="{{ .Root }}index.html">{{ .Site }}</a></header>
+<main>
+<h1>{{ .Title }}</h1>
+{{ .Content }}
+</main>
+{{- with .Backlinks }}
+<aside>
+<h2>Backlinks</h2>
+<ul>
+{{- range . }}
+<li><a href="{{ .URL }}">{{ .Title }}</a></li>
+{{- end }}
+</ul>
+</aside>
+{{- end }}
+</body>
+</html>
+{{ end -}}
+
+{{- define "index" -}}
+{{ template "head" .Site }}
+<body>
+<header><a href="index.html">{{ .Site }}</a></header>
+<main>
+<h1>{{ .Site }}</h1>
+<ul>
+{{- range .Pages }}
+<li><a href="{{ .URL }}">{{ .Title }}</a></li>
+{{- end }}
+</ul>
+</main>
+</body>
+</html>
+{{ end -}}
+`))
@@ -0,0 +1,161 @@
+package wikilink_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+// ctxBatchResolver is a BatchResolver that resolves wikilinks
+// to the value of ctxKey in the context.
+type ctxBatchResolver struct {
+	ctxResolver
+}
+
+func (r ctxBatchResolver) ResolveWikilinks(ctx context.Context, nodes []*wikilink.Node) ([][]byte, error) {
+	dests := make([][]byte, len(nodes))
+	for i, n := range nodes {
+		dest, err := r.ResolveWikilinkContext(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		dests[i] = dest
+	}
+	return dests, nil
+}
+
+// TestConcurrentConvert verifies that a single goldmark Markdown object
+// may be used to convert documents from multiple goroutines
+// with per-conversion state passed through the parser.Context.
+//
+// Run with -race.
+func TestConcurrentConvert(t *testing.T) {
+	t.Parallel()
+
+	const (
+		numWorkers = 8
+		numConvert = 20
+	)
+
+	tests := []struct {
+		desc     string
+		extender *wikilink.Extender
+	}{
+		{
+			desc:     "default",
+			extender: &wikilink.Extender{},
+		},
+		{
+			desc:     "context resolver",
+			extender: &wikilink.Extender{Resolver: ctxResolver{}},
+		},
+		{
+			desc:     "batch resolver",
+			extender: &wikilink.Extender{Resolver: ctxBatchResolver{}},
+		},
+		{
+			desc: "all options",
+			extender: &wikilink.Extender{
+				Resolver: &wikilink.NormalizingResolver{
+					Resolver: ctxResolver{},
+				},
+				Figures: true,
+				Attributer: attributerFunc(func(n *wikilink.Node, _ []byte) []ast.Attribute {
+					return []ast.Attribute{
+						{Name: []byte("class"), Value: []byte("wikilink")},
+					}
+				}),
+				Previewer: previewerFunc(func(n *wikilink.Node, _ []byte) (*wikilink.Preview, error) {
+					return &wikilink.Preview{Title: string(n.Target)}, nil
+				}),
+				ErrorPolicy: wikilink.CollectErrors,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			md := goldmark.New(goldmark.WithExtensions(tt.extender))
+
+			convert := func(worker int) (string, error) {
+				src := fmt.Sprintf(
+					"# Page %d\n\n[[Foo]] and [[Bar#Baz|bar]].\n\n![[image-%d.png]]\n",
+					worker, worker)
+
+				pc := parser.NewContext()
+				wikilink.SetContext(pc, context.WithValue(
+					context.Background(), ctxKey{}, fmt.Sprintf("/worker-%d/", worker)))
+				wikilink.RecordDiagnostics(pc, new(wikilink.Diagnostics))
+				wikilink.RecordPreviews(pc, new(wikilink.PreviewManifest))
+
+				var buf bytes.Buffer
+				err := md.Convert([]byte(src), &buf, parser.WithContext(pc))
+				return buf.String(), err
+			}
+
+			// Record the expected output for each worker
+			// before converting concurrently.
+			want := make([]string, numWorkers)
+			for i := range want {
+				var err error
+				want[i], err = convert(i)
+				require.NoError(t, err)
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < numWorkers; i++ {
+				i := i
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					for j := 0; j < numConvert; j++ {
+						got, err := convert(i)
+						if !assert.NoError(t, err) || !assert.Equal(t, want[i], got) {
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// TestConcurrentRender verifies that a single Renderer
+// may render documents parsed separately from multiple goroutines.
+func TestConcurrentRender(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{}))
+	src := []byte("[[Foo]] [[Bar|bar]] ![[baz.png]]")
+	const want = `<p><a href="Foo.html">Foo</a> <a href="Bar.html">bar</a> <img src="baz.png"></p>` + "\n"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			doc := md.Parser().Parse(text.NewReader(src))
+			var buf bytes.Buffer
+			if assert.NoError(t, md.Renderer().Render(&buf, src, doc)) {
+				assert.Equal(t, want, buf.String())
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,67 @@
+package wikilink
+
+import (
+	"context"
+
+	"github.com/yuin/goldmark/parser"
+)
+
+// _conversionKey is the parser.Context key for the *conversion
+// of the document being converted.
+var _conversionKey = parser.NewContextKey()
+
+// conversion holds per-document state supplied by the user
+// through a parser.Context.
+//
+// The Parser attaches the conversion to every Node it creates
+// so that the Renderer, which does not have access to the parser.Context,
+// can find it.
+type conversion struct {
+	ctx      context.Context
+	previews *PreviewManifest
+	diags    *Diagnostics
+}
+
+// SetContext specifies the context.Context for the conversion
+// using the provided parser.Context.
+//
+//	ctx := parser.NewContext()
+//	wikilink.SetContext(ctx, reqCtx)
+//	err := md.Convert(src, dst, parser.WithContext(ctx))
+//
+// The Renderer passes this context to resolvers that implement
+// ContextResolver, and stops rendering with an error
+// if the context is canceled or its deadline expires.
+func SetContext(pc parser.Context, ctx context.Context) {
+	conversionOf(pc).ctx = ctx
+}
+
+// context returns the context.Context for the conversion
+// that this Node was parsed in.
+func (n *Node) context() context.Context {
+	if n.conv != nil && n.conv.ctx != nil {
+		return n.conv.ctx
+	}
+	return context.Background()
+}
+
+// getConversion returns the conversion stored in the provided context,
+// or nil if there isn't one.
+func getConversion(pc parser.Context) *conversion {
+	if pc == nil {
+		return nil
+	}
+	c, _ := pc.Get(_conversionKey).(*conversion)
+	return c
+}
+
+// conversionOf returns the conversion stored in the provided context,
+// creating it if necessary.
+func conversionOf(pc parser.Context) *conversion {
+	c := getConversion(pc)
+	if c == nil {
+		c = new(conversion)
+		pc.Set(_conversionKey, c)
+	}
+	return c
+}
@@ -0,0 +1,124 @@
+package wikilink_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+type ctxKey struct{}
+
+// ctxResolver resolves wikilinks to the value of ctxKey in the context.
+type ctxResolver struct {
+	resolverFunc
+}
+
+func (ctxResolver) ResolveWikilinkContext(ctx context.Context, n *wikilink.Node) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	prefix, _ := ctx.Value(ctxKey{}).(string)
+	return append([]byte(prefix), n.Target...), nil
+}
+
+func TestContextResolver(t *testing.T) {
+	t.Parallel()
+
+	resolver := ctxResolver{
+		resolverFunc: func(*wikilink.Node) ([]byte, error) {
+			return nil, errors.New("ResolveWikilink must not be called")
+		},
+	}
+
+	tests := []struct {
+		desc     string
+		resolver wikilink.Resolver
+	}{
+		{desc: "direct", resolver: resolver},
+		{
+			desc: "normalizing",
+			resolver: &wikilink.NormalizingResolver{
+				Resolver:   resolver,
+				Normalizer: &wikilink.Normalizer{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+				Resolver: tt.resolver,
+			}))
+
+			pc := parser.NewContext()
+			wikilink.SetContext(pc, context.WithValue(context.Background(), ctxKey{}, "/wiki/"))
+
+			var buf bytes.Buffer
+			require.NoError(t, md.Convert([]byte("[[Foo]]"), &buf, parser.WithContext(pc)))
+			assert.Equal(t, `<p><a href="/wiki/Foo">Foo</a></p>`+"\n", buf.String())
+		})
+	}
+}
+
+func TestContextResolver_noContext(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Resolver: ctxResolver{},
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte("[[Foo]]"), &buf))
+	assert.Equal(t, `<p><a href="Foo">Foo</a></p>`+"\n", buf.String())
+}
+
+func TestSetContext_canceled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		resolver wikilink.Resolver
+	}{
+		{desc: "plain resolver", resolver: wikilink.DefaultResolver},
+		{desc: "context resolver", resolver: ctxResolver{}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			// Errors from canceled conversions must not be
+			// swallowed by the error policy.
+			md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+				Resolver:    tt.resolver,
+				ErrorPolicy: wikilink.RenderErrorAsText,
+			}))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			pc := parser.NewContext()
+			wikilink.SetContext(pc, ctx)
+
+			var buf bytes.Buffer
+			err := md.Convert([]byte("foo [[Bar]]"), &buf, parser.WithContext(pc))
+			require.Error(t, err)
+			assert.ErrorIs(t, err, context.Canceled)
+
+			var resolveErr *wikilink.ResolveError
+			require.ErrorAs(t, err, &resolveErr)
+			assert.Equal(t, "Bar", string(resolveErr.Node.Target))
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package wikilink
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/yuin/goldmark/parser"
+)
+
+// Diagnostic describes a wikilink that could not be rendered as a link.
+type Diagnostic struct {
+	// Node is the wikilink that could not be rendered as a link.
+	Node *Node
+
+	// Line and Column are the 1-indexed position of the wikilink
+	// in the source. Column is measured in bytes.
+	//
+	// These are zero if the Node was not parsed from a source.
+	Line, Column int
+
+	// Err is the error returned by the Resolver for the wikilink.
+	//
+	// This is nil if the Resolver did not return a destination
+	// for the wikilink.
+	Err error
+}
+
+// String returns a human-readable description of the diagnostic.
+//
+//	3:5: unresolved wikilink [[foo]]
+//	3:5: resolve "foo": great sadness
+func (d *Diagnostic) String() string {
+	var buf bytes.Buffer
+	if d.Line > 0 {
+		fmt.Fprintf(&buf, "%d:%d: ", d.Line, d.Column)
+	}
+	if d.Err != nil {
+		fmt.Fprintf(&buf, "resolve %q: %v", d.Node.Target, d.Err)
+	} else {
+		buf.WriteString("unresolved wikilink [[")
+		buf.Write(d.Node.Target)
+		if len(d.Node.Fragment) > 0 {
+			buf.Write(_hash)
+			buf.Write(d.Node.Fragment)
+		}
+		buf.WriteString("]]")
+	}
+	return buf.String()
+}
+
+// Diagnostics collects diagnostics for wikilinks
+// that could not be rendered as links.
+// These are wikilinks for which the Resolver returned an error,
+// or did not return a destination.
+//
+// Attach Diagnostics to a conversion with RecordDiagnostics,
+// and inspect it after the conversion is complete.
+//
+//	var diags wikilink.Diagnostics
+//	ctx := parser.NewContext()
+//	wikilink.RecordDiagnostics(ctx, &diags)
+//	err := md.Convert(src, dst, parser.WithContext(ctx))
+//	// ...
+//	if err := diags.Err(); err != nil {
+//		// ...
+//	}
+//
+// Diagnostics is safe for concurrent use
+// so it may be shared between conversions of multiple documents.
+type Diagnostics struct {
+	mu    sync.Mutex
+	diags []Diagnostic
+}
+
+// RecordDiagnostics specifies that diagnostics for wikilinks rendered
+// during the conversion using the provided parser.Context should be recorded
+// to the given Diagnostics.
+func RecordDiagnostics(pc parser.Context, d *Diagnostics) {
+	conversionOf(pc).diags = d
+}
+
+// List returns the recorded diagnostics in the order they were recorded.
+func (d *Diagnostics) List() []Diagnostic {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]Diagnostic(nil), d.diags...)
+}
+
+// Len reports the number of recorded diagnostics.
+func (d *Diagnostics) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.diags)
+}
+
+// Err returns an error describing all recorded diagnostics,
+// or nil if there are none.
+//
+// Diagnostics for resolver errors are reported as *ResolveError.
+func (d *Diagnostics) Err() error {
+	diags := d.List()
+	errs := make([]error, len(diags))
+	for i, diag := range diags {
+		if diag.Err != nil {
+			errs[i] = &ResolveError{
+				Node:   diag.Node,
+				Line:   diag.Line,
+				Column: diag.Column,
+				Err:    diag.Err,
+			}
+		} else {
+			errs[i] = errors.New(diag.String())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Diagnostics) record(diag Diagnostic) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.diags = append(d.diags, diag)
+}
+
+// diagnose records a diagnostic for the provided Node in its conversion's
+// Diagnostics, if any.
+func diagnose(src []byte, n *Node, err error) {
+	if n.conv == nil || n.conv.diags == nil {
+		return
+	}
+
+	line, col := position(src, n)
+	n.conv.diags.record(Diagnostic{
+		Node:   n,
+		Line:   line,
+		Column: col,
+		Err:    err,
+	})
+}
+
+// position returns the 1-indexed line and column of the provided Node
+// in the source, or zeroes if the position is unknown.
+func position(src []byte, n *Node) (line, col int) {
+	start := n.Segment.Start
+	if n.Segment.Len() == 0 || start > len(src) {
+		return 0, 0
+	}
+
+	before := src[:start]
+	line = bytes.Count(before, []byte{'\n'}) + 1
+	col = start - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
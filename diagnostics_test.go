@@ -0,0 +1,105 @@
+package wikilink_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Resolver: _resolver,
+	}))
+
+	src := "# Title\n\n" +
+		"See [[Foo]] and [[Does Not Exist]].\n" +
+		"\n" +
+		"- Also [[Does Not Exist#Bar|baz]].\n"
+
+	var diags wikilink.Diagnostics
+	pc := parser.NewContext()
+	wikilink.RecordDiagnostics(pc, &diags)
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte(src), &buf, parser.WithContext(pc)))
+
+	list := diags.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, 2, diags.Len())
+
+	assert.Equal(t, "Does Not Exist", string(list[0].Node.Target))
+	assert.Equal(t, 3, list[0].Line)
+	assert.Equal(t, 17, list[0].Column)
+	assert.NoError(t, list[0].Err)
+
+	assert.Equal(t, "Does Not Exist", string(list[1].Node.Target))
+	assert.Equal(t, "Bar", string(list[1].Node.Fragment))
+	assert.Equal(t, 5, list[1].Line)
+	assert.Equal(t, 8, list[1].Column)
+
+	err := diags.Err()
+	require.Error(t, err)
+	assert.Equal(t,
+		"3:17: unresolved wikilink [[Does Not Exist]]\n"+
+			"5:8: unresolved wikilink [[Does Not Exist#Bar]]",
+		err.Error())
+}
+
+func TestDiagnostics_resolveError(t *testing.T) {
+	t.Parallel()
+
+	giveErr := errors.New("great sadness")
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Resolver: resolverFunc(func(*wikilink.Node) ([]byte, error) {
+			return nil, giveErr
+		}),
+	}))
+
+	var diags wikilink.Diagnostics
+	pc := parser.NewContext()
+	wikilink.RecordDiagnostics(pc, &diags)
+
+	var buf bytes.Buffer
+	require.Error(t, md.Convert([]byte("foo\n\nbar [[baz]]"), &buf, parser.WithContext(pc)))
+
+	list := diags.List()
+	require.Len(t, list, 1)
+	assert.ErrorIs(t, list[0].Err, giveErr)
+	assert.Equal(t, `3:5: resolve "baz": great sadness`, list[0].String())
+}
+
+func TestDiagnostics_empty(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{}))
+
+	var diags wikilink.Diagnostics
+	pc := parser.NewContext()
+	wikilink.RecordDiagnostics(pc, &diags)
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte("[[foo]]"), &buf, parser.WithContext(pc)))
+	assert.Empty(t, diags.List())
+	assert.NoError(t, diags.Err())
+}
+
+func TestDiagnostic_String(t *testing.T) {
+	t.Parallel()
+
+	d := wikilink.Diagnostic{Node: &wikilink.Node{Target: []byte("foo")}}
+	assert.Equal(t, "unresolved wikilink [[foo]]", d.String())
+}
+
+type resolverFunc func(*wikilink.Node) ([]byte, error)
+
+func (f resolverFunc) ResolveWikilink(n *wikilink.Node) ([]byte, error) {
+	return f(n)
+}
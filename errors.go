@@ -0,0 +1,143 @@
+package wikilink
+
+import (
+	"fmt"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/util"
+)
+
+// ErrorPolicy specifies how the Renderer handles errors
+// returned by the Resolver.
+type ErrorPolicy int
+
+const (
+	// AbortOnError halts rendering of the document
+	// with a *ResolveError.
+	//
+	// This is the default.
+	AbortOnError ErrorPolicy = iota
+
+	// RenderErrorAsText renders the label of the failed wikilink
+	// as plain text, the same as a wikilink without a destination,
+	// and continues rendering.
+	RenderErrorAsText
+
+	// RenderErrorAsBrokenLink renders the failed wikilink
+	// as a link without a destination
+	// and continues rendering.
+	//
+	//	<a class="wikilink-broken">label</a>
+	//
+	// The class may be changed with Renderer.BrokenLinkClass.
+	RenderErrorAsBrokenLink
+
+	// CollectErrors records the failed wikilink
+	// in the Diagnostics attached to the conversion with RecordDiagnostics,
+	// renders it as plain text, and continues rendering.
+	// Inspect the Diagnostics after the conversion to handle the errors.
+	//
+	// If the conversion does not have Diagnostics,
+	// CollectErrors behaves like AbortOnError.
+	CollectErrors
+)
+
+// DefaultBrokenLinkClass is the class used for broken links
+// rendered by the RenderErrorAsBrokenLink policy
+// if the Renderer does not specify one.
+const DefaultBrokenLinkClass = "wikilink-broken"
+
+// ResolveError is the error reported when the Resolver fails
+// to resolve a wikilink.
+//
+// Use errors.As to retrieve it from errors returned by goldmark.
+//
+//	var resolveErr *wikilink.ResolveError
+//	if errors.As(err, &resolveErr) {
+//		// ...
+//	}
+type ResolveError struct {
+	// Node is the wikilink that failed to resolve.
+	Node *Node
+
+	// Line and Column are the 1-indexed position of the wikilink
+	// in the source. Column is measured in bytes.
+	//
+	// These are zero if the Node was not parsed from a source.
+	Line, Column int
+
+	// Err is the error returned by the Resolver.
+	Err error
+}
+
+func newResolveError(src []byte, n *Node, err error) *ResolveError {
+	line, col := position(src, n)
+	return &ResolveError{
+		Node:   n,
+		Line:   line,
+		Column: col,
+		Err:    err,
+	}
+}
+
+// Error returns a message describing the failure,
+// prefixed with its position if known.
+//
+//	3:5: resolve "foo": great sadness
+func (e *ResolveError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%d:%d: resolve %q: %v", e.Line, e.Column, e.Node.Target, e.Err)
+	}
+	return fmt.Sprintf("resolve %q: %v", e.Node.Target, e.Err)
+}
+
+// Unwrap returns the error returned by the Resolver.
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
+
+// resolveFailed handles an error returned by the Resolver for the provided
+// Node based on the ErrorPolicy.
+//
+// If handled is false, the caller should render the Node
+// as if it had no destination.
+func (r *Renderer) resolveFailed(
+	w util.BufWriter, src []byte, n *Node, err error,
+) (status ast.WalkStatus, handled bool, _ error) {
+	diagnose(src, n, err)
+
+	switch r.ErrorPolicy {
+	case RenderErrorAsText:
+		return ast.WalkContinue, false, nil
+
+	case RenderErrorAsBrokenLink:
+		class := r.BrokenLinkClass
+		if class == "" {
+			class = DefaultBrokenLinkClass
+		}
+
+		n.open = true
+		_, _ = w.WriteString(`<a class="`)
+		_, _ = w.Write(util.EscapeHTML(util.StringToReadOnlyBytes(class)))
+		_, _ = w.WriteString(`">`)
+		return ast.WalkContinue, true, nil
+	}
+
+	if !r.abortsOnError(n) {
+		return ast.WalkContinue, false, nil
+	}
+	return ast.WalkStop, true, newResolveError(src, n, err)
+}
+
+// abortsOnError reports whether a failure to resolve the provided Node
+// halts rendering under the Renderer's ErrorPolicy.
+func (r *Renderer) abortsOnError(n *Node) bool {
+	switch r.ErrorPolicy {
+	case RenderErrorAsText, RenderErrorAsBrokenLink:
+		return false
+	case CollectErrors:
+		return n.conv == nil || n.conv.diags == nil
+	default:
+		return true
+	}
+}
@@ -0,0 +1,135 @@
+package wikilink_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+var errSadness = errors.New("great sadness")
+
+// sadResolver fails to resolve wikilinks with the target "Sad".
+var sadResolver = resolverFunc(func(n *wikilink.Node) ([]byte, error) {
+	if string(n.Target) == "Sad" {
+		return nil, errSadness
+	}
+	return wikilink.DefaultResolver.ResolveWikilink(n)
+})
+
+func TestErrorPolicy(t *testing.T) {
+	t.Parallel()
+
+	const src = "[[Foo]] and [[Sad|so sad]] and ![[Sad]]\n"
+
+	tests := []struct {
+		desc        string
+		extender    wikilink.Extender
+		diagnostics bool
+
+		want      string
+		wantErr   bool
+		wantDiags int
+	}{
+		{
+			desc:     "abort",
+			extender: wikilink.Extender{ErrorPolicy: wikilink.AbortOnError},
+			wantErr:  true,
+		},
+		{
+			desc:        "abort/diagnostics",
+			extender:    wikilink.Extender{ErrorPolicy: wikilink.AbortOnError},
+			diagnostics: true,
+			wantErr:     true,
+			wantDiags:   1,
+		},
+		{
+			desc:     "text",
+			extender: wikilink.Extender{ErrorPolicy: wikilink.RenderErrorAsText},
+			want:     `<p><a href="Foo.html">Foo</a> and so sad and Sad</p>`,
+		},
+		{
+			desc:        "text/diagnostics",
+			extender:    wikilink.Extender{ErrorPolicy: wikilink.RenderErrorAsText},
+			diagnostics: true,
+			want:        `<p><a href="Foo.html">Foo</a> and so sad and Sad</p>`,
+			wantDiags:   2,
+		},
+		{
+			desc:     "broken link",
+			extender: wikilink.Extender{ErrorPolicy: wikilink.RenderErrorAsBrokenLink},
+			want: `<p><a href="Foo.html">Foo</a> and ` +
+				`<a class="wikilink-broken">so sad</a> and ` +
+				`<a class="wikilink-broken">Sad</a></p>`,
+		},
+		{
+			desc: "broken link/custom class",
+			extender: wikilink.Extender{
+				ErrorPolicy:     wikilink.RenderErrorAsBrokenLink,
+				BrokenLinkClass: "oops",
+			},
+			want: `<p><a href="Foo.html">Foo</a> and ` +
+				`<a class="oops">so sad</a> and ` +
+				`<a class="oops">Sad</a></p>`,
+		},
+		{
+			desc:     "collect/no diagnostics",
+			extender: wikilink.Extender{ErrorPolicy: wikilink.CollectErrors},
+			wantErr:  true,
+		},
+		{
+			desc:        "collect",
+			extender:    wikilink.Extender{ErrorPolicy: wikilink.CollectErrors},
+			diagnostics: true,
+			want:        `<p><a href="Foo.html">Foo</a> and so sad and Sad</p>`,
+			wantDiags:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ext := tt.extender
+			ext.Resolver = sadResolver
+			md := goldmark.New(goldmark.WithExtensions(&ext))
+
+			pc := parser.NewContext()
+			var diags wikilink.Diagnostics
+			if tt.diagnostics {
+				wikilink.RecordDiagnostics(pc, &diags)
+			}
+
+			var buf bytes.Buffer
+			err := md.Convert([]byte(src), &buf, parser.WithContext(pc))
+			assert.Equal(t, tt.wantDiags, diags.Len(), "diagnostics")
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, errSadness)
+
+				var resolveErr *wikilink.ResolveError
+				require.ErrorAs(t, err, &resolveErr)
+				assert.Equal(t, "Sad", string(resolveErr.Node.Target))
+				assert.Equal(t, 1, resolveErr.Line)
+				assert.Equal(t, 13, resolveErr.Column)
+				assert.Equal(t, `1:13: resolve "Sad": great sadness`, resolveErr.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want+"\n", buf.String())
+
+			if tt.wantDiags > 0 {
+				var resolveErr *wikilink.ResolveError
+				require.ErrorAs(t, diags.Err(), &resolveErr)
+				assert.ErrorIs(t, resolveErr, errSadness)
+			}
+		})
+	}
+}
@@ -9,11 +9,74 @@ import (
 
 // Extender extends a goldmark Markdown object with support for parsing and
 // rendering Wikilinks.
+//
+// A goldmark Markdown object extended with an Extender
+// may be used to convert documents from multiple goroutines concurrently
+// if the Resolver, Attributer, and Previewer are safe for concurrent use.
+// See Renderer for details.
 type Extender struct {
 	// Resoler specifies how to resolve destinations for linked pages.
 	//
+	// If the Resolver is a BatchResolver,
+	// all wikilinks in a document are resolved at once after parsing.
+	// See BatchTransformer for details.
+	//
 	// Uses DefaultResolver if unspecified.
 	Resolver Resolver
+
+	// Dialect specifies extensions to the wikilink syntax to support.
+	//
+	// Defaults to DefaultDialect.
+	Dialect Dialect
+
+	// WikiWords specifies whether CamelCase words like WikiWord
+	// should be parsed as wikilinks.
+	//
+	// See WikiWordParser for details.
+	WikiWords bool
+
+	// Figures specifies whether image embeds that stand alone
+	// in a paragraph should be rendered as figures
+	// with the label as the caption.
+	//
+	// See FigureTransformer for details.
+	Figures bool
+
+	// AutoLink links mentions of known pages in plain text
+	// to those pages.
+	//
+	// See AutoLinkTransformer for details.
+	AutoLink *AutoLinkTransformer
+
+	// Attributer computes additional HTML attributes
+	// for the tags generated for wikilinks.
+	//
+	// See Attributer for details.
+	Attributer Attributer
+
+	// Templates specifies templates to render wikilinks with
+	// instead of the default HTML.
+	//
+	// See Templates for details.
+	Templates *Templates
+
+	// Previewer provides previews of linked pages
+	// to render as data-preview-* attributes on links.
+	//
+	// See Previewer for details.
+	Previewer Previewer
+
+	// ErrorPolicy specifies how errors returned by the Resolver
+	// are handled.
+	//
+	// Defaults to AbortOnError.
+	ErrorPolicy ErrorPolicy
+
+	// BrokenLinkClass is the class of links rendered by
+	// the RenderErrorAsBrokenLink policy.
+	//
+	// Defaults to DefaultBrokenLinkClass if unspecified.
+	BrokenLinkClass string
 }
 
 // Extend extends the provided Markdown object with support for wikilinks.
@@ -22,16 +85,57 @@ func (e *Extender) Extend(md goldmark.Markdown) {
 	// lower than that to ensure that the "[" trigger fires.
 	md.Parser().AddOptions(
 		parser.WithInlineParsers(
-			util.Prioritized(&Parser{}, 199),
+			util.Prioritized(&Parser{Dialect: e.Dialect}, 199),
 		),
 	)
 
+	if e.WikiWords {
+		md.Parser().AddOptions(
+			parser.WithInlineParsers(
+				util.Prioritized(&WikiWordParser{}, 198),
+			),
+		)
+	}
+
+	if e.Figures {
+		md.Parser().AddOptions(
+			parser.WithASTTransformers(
+				util.Prioritized(&FigureTransformer{}, 199),
+			),
+		)
+	}
+
+	if e.AutoLink != nil {
+		md.Parser().AddOptions(
+			parser.WithASTTransformers(
+				util.Prioritized(e.AutoLink, 150),
+			),
+		)
+	}
+
+	// Resolve wikilinks after other transformers have run
+	// in case they add or remove wikilinks.
+	// Transformers run in increasing order of priority.
+	if br, ok := e.Resolver.(BatchResolver); ok {
+		md.Parser().AddOptions(
+			parser.WithASTTransformers(
+				util.Prioritized(&BatchTransformer{Resolver: br}, 1000),
+			),
+		)
+	}
+
 	// The renderer priority matters less. Use the same just so that
 	// there's a reasonable expected value.
 	md.Renderer().AddOptions(
 		renderer.WithNodeRenderers(
 			util.Prioritized(&Renderer{
-				Resolver: e.Resolver,
+				Resolver:   e.Resolver,
+				Attributer: e.Attributer,
+				Templates:  e.Templates,
+				Previewer:  e.Previewer,
+
+				ErrorPolicy:     e.ErrorPolicy,
+				BrokenLinkClass: e.BrokenLinkClass,
 			}, 199),
 		),
 	)
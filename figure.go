@@ -0,0 +1,203 @@
+package wikilink
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// FigureKind is the kind of the Figure AST node.
+var FigureKind = ast.NewNodeKind("WikiLinkFigure")
+
+// Figure is a block AST node that presents an embedded image
+// with its label as a caption.
+//
+// Figures are created by FigureTransformer
+// from image embeds that are alone in a paragraph.
+// A Figure has exactly one child: the wikilink [Node] for the image.
+type Figure struct {
+	ast.BaseBlock
+
+	// lookahead holds the resolution of the image
+	// for the render in progress, until the image is rendered.
+	// The Renderer resolves the image when entering the Figure
+	// to decide how to render it.
+	//
+	// As with Node.open, this is reset every time the Figure is entered.
+	lookahead *resolution
+
+	// figure records whether the Renderer wrote an opening <figure> tag
+	// when entering this node, rather than a <p> tag.
+	figure bool
+}
+
+var _ ast.Node = (*Figure)(nil)
+
+// Kind reports the kind of this node.
+func (f *Figure) Kind() ast.NodeKind {
+	return FigureKind
+}
+
+// Dump dumps the Figure to stdout.
+func (f *Figure) Dump(src []byte, level int) {
+	ast.DumpHelper(f, src, level, nil, nil)
+}
+
+// FigureTransformer is a goldmark AST transformer that turns image embeds
+// that stand alone in a paragraph into figures.
+// For example,
+//
+//	![[foo.png|A foo]]
+//
+// Will be rendered as the following
+// instead of an image inside a paragraph.
+//
+//	<figure>
+//	<img src="foo.png" alt="A foo">
+//	<figcaption>A foo</figcaption>
+//	</figure>
+//
+// Install it on your goldmark Markdown object with Extender by setting
+// Extender.Figures, or install it directly on your goldmark Parser by using
+// the WithASTTransformers option.
+//
+//	figureTransformer := util.Prioritized(&wikilink.FigureTransformer{}, 199)
+//	goldmarkParser.AddOptions(parser.WithASTTransformers(figureTransformer))
+//
+// Figures are rendered by Renderer.
+// If the image does not resolve to a destination,
+// the figure is rendered as a paragraph instead.
+type FigureTransformer struct{}
+
+var _ parser.ASTTransformer = (*FigureTransformer)(nil)
+
+// Transform replaces paragraphs that hold only an image embed
+// with Figure nodes.
+func (t *FigureTransformer) Transform(doc *ast.Document, _ text.Reader, _ parser.Context) {
+	// Collect the paragraphs first because replacing nodes
+	// while walking the tree would break the walk.
+	var paras []*ast.Paragraph
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Paragraph:
+			if n.ChildCount() != 1 {
+				return ast.WalkSkipChildren, nil
+			}
+			if link, ok := n.FirstChild().(*Node); ok && resolveAsImage(link) {
+				paras = append(paras, n)
+			}
+			return ast.WalkSkipChildren, nil
+		case *Figure:
+			return ast.WalkSkipChildren, nil
+		default:
+			return ast.WalkContinue, nil
+		}
+	})
+
+	for _, para := range paras {
+		fig := &Figure{}
+		fig.SetLines(para.Lines())
+		fig.AppendChild(fig, para.FirstChild())
+		para.Parent().ReplaceChild(para.Parent(), para, fig)
+	}
+}
+
+func (r *Renderer) renderFigure(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	fig, ok := node.(*Figure)
+	if !ok {
+		return ast.WalkStop, fmt.Errorf("unexpected node %T, expected *wikilink.Figure", node)
+	}
+
+	if entering {
+		return r.enterFigure(w, src, fig)
+	}
+
+	if !fig.figure {
+		_, _ = w.WriteString("</p>\n")
+		return ast.WalkContinue, nil
+	}
+
+	_ = w.WriteByte('\n')
+	if caption := figureCaption(src, fig); len(caption) > 0 {
+		_, _ = w.WriteString("<figcaption>")
+		_, _ = w.Write(util.EscapeHTML(caption))
+		_, _ = w.WriteString("</figcaption>\n")
+	}
+	_, _ = w.WriteString("</figure>\n")
+	return ast.WalkContinue, nil
+}
+
+// enterFigure resolves the image inside the provided Figure
+// and opens a <figure> if it will be rendered.
+// Images that won't be rendered, for example because they
+// failed to resolve, are left in a paragraph like other wikilinks.
+//
+// Errors that halt rendering are reported here
+// before anything is written.
+func (r *Renderer) enterFigure(w util.BufWriter, src []byte, fig *Figure) (ast.WalkStatus, error) {
+	fig.lookahead = nil
+	fig.figure = false
+
+	n, ok := fig.FirstChild().(*Node)
+	if !ok {
+		_, _ = w.WriteString("<p>")
+		return ast.WalkContinue, nil
+	}
+
+	dest, err := r.resolve(n)
+	if err != nil {
+		if ctxErr := n.context().Err(); ctxErr != nil {
+			return ast.WalkStop, newResolveError(src, n, ctxErr)
+		}
+		if r.abortsOnError(n) {
+			_, _, err := r.resolveFailed(w, src, n, err)
+			return ast.WalkStop, err
+		}
+	}
+	fig.lookahead = &resolution{dest: dest, err: err}
+
+	if err != nil || len(dest) == 0 {
+		_, _ = w.WriteString("<p>")
+		return ast.WalkContinue, nil
+	}
+
+	fig.figure = true
+	_, _ = w.WriteString("<figure>\n")
+	return ast.WalkContinue, nil
+}
+
+// lookahead returns the resolution of the provided Node
+// made by the Figure that holds it for the render in progress, if any.
+// The resolution is used only once.
+func lookahead(n *Node) *resolution {
+	fig, ok := n.Parent().(*Figure)
+	if !ok || fig.lookahead == nil {
+		return nil
+	}
+	res := fig.lookahead
+	fig.lookahead = nil
+	return res
+}
+
+// figureCaption returns the caption for the provided Figure, if any.
+//
+// As with alt text, the label of the image is used as the caption
+// only if it isn't the same as the target.
+func figureCaption(src []byte, fig ast.Node) []byte {
+	n, ok := fig.FirstChild().(*Node)
+	if !ok || n.ChildCount() != 1 {
+		return nil
+	}
+	if label := nodeText(src, n.FirstChild()); !bytes.Equal(label, n.Target) {
+		return label
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package wikilink_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/text"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+func TestFigures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give string
+		want string
+	}{
+		{
+			desc: "label",
+			give: "![[foo.png|A foo]]\n",
+			want: "<figure>\n" +
+				`<img src="foo.png" alt="A foo">` + "\n" +
+				"<figcaption>A foo</figcaption>\n" +
+				"</figure>\n",
+		},
+		{
+			desc: "no label",
+			give: "![[foo.png]]\n",
+			want: "<figure>\n" +
+				`<img src="foo.png">` + "\n" +
+				"</figure>\n",
+		},
+		{
+			desc: "label escaped",
+			give: "![[foo.png|<b>&</b>]]\n",
+			want: "<figure>\n" +
+				`<img src="foo.png" alt="&lt;b&gt;&amp;&lt;/b&gt;">` + "\n" +
+				"<figcaption>&lt;b&gt;&amp;&lt;/b&gt;</figcaption>\n" +
+				"</figure>\n",
+		},
+		{
+			desc: "nested in block quote",
+			give: "> ![[foo.png|bar]]\n",
+			want: "<blockquote>\n" +
+				"<figure>\n" +
+				`<img src="foo.png" alt="bar">` + "\n" +
+				"<figcaption>bar</figcaption>\n" +
+				"</figure>\n" +
+				"</blockquote>\n",
+		},
+		{
+			desc: "inside text",
+			give: "See ![[foo.png|bar]] here.\n",
+			want: `<p>See <img src="foo.png" alt="bar"> here.</p>` + "\n",
+		},
+		{
+			desc: "multiple images",
+			give: "![[foo.png]] ![[bar.png]]\n",
+			want: `<p><img src="foo.png"> <img src="bar.png"></p>` + "\n",
+		},
+		{
+			desc: "not an image",
+			give: "![[foo.pdf|bar]]\n",
+			want: `<p><a href="foo.pdf">bar</a></p>` + "\n",
+		},
+		{
+			desc: "not an embed",
+			give: "[[foo.png|bar]]\n",
+			want: `<p><a href="foo.png">bar</a></p>` + "\n",
+		},
+	}
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Figures: true,
+	}))
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			require.NoError(t, md.Convert([]byte(tt.give), &buf))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestFigures_unresolved(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	resolver := resolverFunc(func(n *wikilink.Node) ([]byte, error) {
+		calls++
+		switch string(n.Target) {
+		case "missing.png":
+			return nil, nil
+		case "error.png":
+			return nil, errors.New("great sadness")
+		}
+		return n.Target, nil
+	})
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Resolver:    resolver,
+		Figures:     true,
+		ErrorPolicy: wikilink.RenderErrorAsBrokenLink,
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte(
+		"![[missing.png|cap]]\n\n"+
+			"![[error.png|cap]]\n\n"+
+			"![[foo.png|cap]]\n",
+	), &buf))
+	assert.Equal(t,
+		"<p>cap</p>\n"+
+			`<p><a class="wikilink-broken">cap</a></p>`+"\n"+
+			"<figure>\n"+
+			`<img src="foo.png" alt="cap">`+"\n"+
+			"<figcaption>cap</figcaption>\n"+
+			"</figure>\n",
+		buf.String())
+	assert.Equal(t, 3, calls, "each image must be resolved once")
+}
+
+func TestFigures_abortOnError(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Resolver: resolverFunc(func(*wikilink.Node) ([]byte, error) {
+			return nil, errors.New("great sadness")
+		}),
+		Figures: true,
+	}))
+
+	src := []byte("before\n\n![[foo.png|cap]]\n")
+	doc := md.Parser().Parse(text.NewReader(src))
+
+	// goldmark writes to a util.BufWriter as-is,
+	// so we can see what was written before the error.
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	err := md.Renderer().Render(w, src, doc)
+	require.NoError(t, w.Flush())
+
+	var resolveErr *wikilink.ResolveError
+	require.ErrorAs(t, err, &resolveErr)
+	assert.Equal(t, "foo.png", string(resolveErr.Node.Target))
+	assert.Equal(t, "<p>before</p>\n", buf.String(),
+		"nothing must be written for the figure")
+}
+
+func TestFigures_rerender(t *testing.T) {
+	t.Parallel()
+
+	newMarkdown := func(resolver wikilink.Resolver) goldmark.Markdown {
+		return goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+			Resolver: resolver,
+			Figures:  true,
+		}))
+	}
+	found := newMarkdown(resolverFunc(func(n *wikilink.Node) ([]byte, error) {
+		return n.Target, nil
+	}))
+	missing := newMarkdown(resolverFunc(func(*wikilink.Node) ([]byte, error) {
+		return nil, nil
+	}))
+
+	src := []byte("![[foo.png|cap]]\n")
+	doc := found.Parser().Parse(text.NewReader(src))
+
+	// The same AST must render according to each renderer's resolver.
+	tests := []struct {
+		md   goldmark.Markdown
+		want string
+	}{
+		{missing, "<p>cap</p>\n"},
+		{found, "<figure>\n" +
+			`<img src="foo.png" alt="cap">` + "\n" +
+			"<figcaption>cap</figcaption>\n" +
+			"</figure>\n"},
+		{missing, "<p>cap</p>\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		require.NoError(t, tt.md.Renderer().Render(&buf, src, doc))
+		assert.Equal(t, tt.want, buf.String())
+	}
+}
+
+func TestFigures_disabled(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{}))
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte("![[foo.png|bar]]\n"), &buf))
+	assert.Equal(t, `<p><img src="foo.png" alt="bar"></p>`+"\n", buf.String())
+}
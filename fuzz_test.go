@@ -0,0 +1,202 @@
+package wikilink_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"go.abhg.dev/goldmark/wikilink"
+	"gopkg.in/yaml.v3"
+)
+
+// FuzzConvert converts arbitrary Markdown with the wikilink extension
+// and verifies that:
+//
+//   - conversion does not fail or panic
+//   - the output is well-formed (X)HTML
+//   - wikilink targets and fragments never contain the closing delimiter
+//   - wikilinks survive a round trip through MarkdownRenderer
+func FuzzConvert(f *testing.F) {
+	fuzzConvert(f, wikilink.DefaultDialect)
+}
+
+// FuzzConvertLogseq is a variant of FuzzConvert for the LogseqDialect.
+// Targets may contain the closing delimiter in nested wikilinks,
+// but the output must still be well-formed.
+func FuzzConvertLogseq(f *testing.F) {
+	f.Add("[[outer [[inner]] page]]")
+	f.Add("[[a|b [[c]]]] {{embed [[d [[e]]]]}}")
+	f.Add("[[a [[b]] c")
+	fuzzConvert(f, wikilink.LogseqDialect)
+}
+
+func fuzzConvert(f *testing.F, dialect wikilink.Dialect) {
+	for _, give := range fuzzSeeds(f) {
+		f.Add(give)
+	}
+
+	md := goldmark.New(
+		goldmark.WithExtensions(&wikilink.Extender{Dialect: dialect}),
+		goldmark.WithRendererOptions(html.WithXHTML()),
+	)
+
+	f.Fuzz(func(t *testing.T, give string) {
+		src := []byte(give)
+		doc := md.Parser().Parse(text.NewReader(src))
+
+		var buf bytes.Buffer
+		require.NoError(t, md.Renderer().Render(&buf, src, doc), "render")
+		if xmlSafe(src) {
+			requireWellFormed(t, buf.Bytes())
+		}
+
+		_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if link, ok := n.(*wikilink.Node); ok && entering {
+				if dialect == wikilink.DefaultDialect {
+					assert.NotContains(t, string(link.Target), "]]", "target")
+					assert.NotContains(t, string(link.Fragment), "]]", "fragment")
+				}
+				assert.NotContains(t, string(link.Target), "\n", "target")
+				requireRoundTrip(t, src, link, dialect)
+			}
+			return ast.WalkContinue, nil
+		})
+	})
+}
+
+// FuzzRenderer renders wikilink nodes with arbitrary contents
+// and verifies that the output is well-formed (X)HTML.
+func FuzzRenderer(f *testing.F) {
+	f.Add("foo", "", "foo", false)
+	f.Add("foo bar", "baz", "qux", false)
+	f.Add("foo.png", "", "a <b> & \"c\"", true)
+	f.Add(`"><script>`, `"'`, "<", true)
+	f.Add("javascript:alert(1)", "", "x", false)
+
+	md := goldmark.New(
+		goldmark.WithExtensions(&wikilink.Extender{Figures: true}),
+		goldmark.WithRendererOptions(html.WithXHTML()),
+	)
+
+	f.Fuzz(func(t *testing.T, target, fragment, label string, embed bool) {
+		for _, s := range []string{target, fragment, label} {
+			if !xmlSafe([]byte(s)) {
+				t.Skip()
+			}
+		}
+
+		link := &wikilink.Node{
+			Target:   []byte(target),
+			Fragment: []byte(fragment),
+			Embed:    embed,
+		}
+		link.AppendChild(link, ast.NewString([]byte(label)))
+
+		para := ast.NewParagraph()
+		para.AppendChild(para, link)
+		doc := ast.NewDocument()
+		doc.AppendChild(doc, para)
+
+		var buf bytes.Buffer
+		require.NoError(t, md.Renderer().Render(&buf, nil /* source */, doc), "render")
+		requireWellFormed(t, buf.Bytes())
+	})
+}
+
+// requireRoundTrip renders the given wikilink with MarkdownRenderer,
+// parses the result, and verifies that the two wikilinks are identical.
+func requireRoundTrip(t testing.TB, src []byte, link *wikilink.Node, dialect wikilink.Dialect) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	var mdr wikilink.MarkdownRenderer
+	_, err := mdr.Render(w, src, link, true /* entering */)
+	require.NoError(t, err, "render markdown")
+	require.NoError(t, w.Flush())
+
+	out := buf.Bytes()
+	p := wikilink.Parser{Dialect: dialect}
+	got, ok := p.Parse(nil /* parent */, text.NewReader(out), parser.NewContext()).(*wikilink.Node)
+	require.True(t, ok, "round trip of %q produced %q: not a wikilink", link.Segment.Value(src), out)
+
+	assert.Equal(t, string(link.Target), string(got.Target), "target")
+	assert.Equal(t, string(link.Fragment), string(got.Fragment), "fragment")
+	assert.Equal(t, link.Embed, got.Embed, "embed")
+	assert.Equal(t, string(label(src, link)), string(label(out, got)), "label")
+}
+
+func label(src []byte, n *wikilink.Node) []byte {
+	var buf bytes.Buffer
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if t, ok := c.(*ast.Text); ok {
+			buf.Write(t.Segment.Value(src))
+		}
+	}
+	return buf.Bytes()
+}
+
+// requireWellFormed verifies that the given XHTML fragment is well-formed.
+func requireWellFormed(t testing.TB, out []byte) {
+	var doc bytes.Buffer
+	doc.WriteString("<root>")
+	doc.Write(out)
+	doc.WriteString("</root>")
+
+	dec := xml.NewDecoder(&doc)
+	dec.Entity = xml.HTMLEntity
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		require.NoError(t, err, "malformed output:\n%s", out)
+	}
+}
+
+// xmlSafe reports whether the source consists only of characters
+// that may appear in an XML document.
+// goldmark passes other characters through to the output as-is,
+// which would be reported as malformed by the XML decoder.
+func xmlSafe(src []byte) bool {
+	if !utf8.Valid(src) {
+		return false
+	}
+	for _, r := range string(src) {
+		if r == '\n' || r == '\t' || r == '\r' {
+			continue
+		}
+		if unicode.IsControl(r) || r == utf8.RuneError || r == 0xFFFE || r == 0xFFFF {
+			return false
+		}
+	}
+	return true
+}
+
+// fuzzSeeds returns the inputs from testdata/tests.yaml.
+func fuzzSeeds(t testing.TB) []string {
+	testsdata, err := os.ReadFile("testdata/tests.yaml")
+	require.NoError(t, err)
+
+	var tests []struct {
+		Give string `yaml:"give"`
+	}
+	require.NoError(t, yaml.Unmarshal(testsdata, &tests))
+
+	seeds := make([]string, len(tests))
+	for i, tt := range tests {
+		seeds[i] = tt.Give
+	}
+	return seeds
+}
@@ -0,0 +1,240 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+//
+// Attachments are drawn as boxes and missing files with dashed outlines.
+// Embeds are drawn as dashed edges,
+// and fragments are used as edge labels.
+//
+//	digraph wikilinks {
+//		"Foo.md" [label="Foo"];
+//		"Bar.md" [label="Bar"];
+//		"Foo.md" -> "Bar.md" [label="Baz"];
+//	}
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("digraph wikilinks {\n")
+	for _, n := range g.Nodes {
+		_, _ = bw.WriteString("\t" + dotQuote(n.ID) + " [label=" + dotQuote(n.Label))
+		switch n.Kind {
+		case Attachment:
+			_, _ = bw.WriteString(", shape=box")
+		case Missing:
+			_, _ = bw.WriteString(", style=dashed")
+		}
+		_, _ = bw.WriteString("];\n")
+	}
+	for _, e := range g.Edges {
+		_, _ = bw.WriteString("\t" + dotQuote(e.From) + " -> " + dotQuote(e.To))
+
+		var attrs []string
+		if e.Fragment != "" {
+			attrs = append(attrs, "label="+dotQuote(e.Fragment))
+		}
+		if e.Embed {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(attrs) > 0 {
+			_, _ = bw.WriteString(" [" + strings.Join(attrs, ", ") + "]")
+		}
+		_, _ = bw.WriteString(";\n")
+	}
+	_, _ = bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// dotQuote returns s as a quoted DOT identifier.
+func dotQuote(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s) + 2)
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			// Not meaningful in a label.
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// jsonGraph is the JSON representation of a graph
+// in the shape expected by d3-force.
+type jsonGraph struct {
+	Nodes []jsonNode `json:"nodes"`
+	Links []jsonLink `json:"links"`
+}
+
+type jsonNode struct {
+	ID    string   `json:"id"`
+	Label string   `json:"label"`
+	Kind  string   `json:"kind"`
+	Group string   `json:"group"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+type jsonLink struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Embed    bool   `json:"embed,omitempty"`
+	Fragment string `json:"fragment,omitempty"`
+}
+
+// WriteJSON writes the graph as a JSON object with "nodes" and "links",
+// suitable for use with d3-force.
+// Nodes are grouped by the folder they're in.
+//
+//	{
+//	  "nodes": [
+//	    {"id": "notes/Foo.md", "label": "Foo", "kind": "page", "group": "notes", "tags": ["a"]},
+//	    {"id": "img.png", "label": "img.png", "kind": "attachment", "group": ""}
+//	  ],
+//	  "links": [
+//	    {"source": "notes/Foo.md", "target": "img.png", "embed": true}
+//	  ]
+//	}
+func (g *Graph) WriteJSON(w io.Writer) error {
+	out := jsonGraph{
+		Nodes: make([]jsonNode, len(g.Nodes)),
+		Links: make([]jsonLink, len(g.Edges)),
+	}
+	for i, n := range g.Nodes {
+		out.Nodes[i] = jsonNode{
+			ID:    n.ID,
+			Label: n.Label,
+			Kind:  n.Kind.String(),
+			Group: n.Folder(),
+			Tags:  n.Tags,
+		}
+	}
+	for i, e := range g.Edges {
+		out.Links[i] = jsonLink{
+			Source:   e.From,
+			Target:   e.To,
+			Embed:    e.Embed,
+			Fragment: e.Fragment,
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// GraphML document structure.
+type (
+	graphML struct {
+		XMLName xml.Name     `xml:"graphml"`
+		XMLNS   string       `xml:"xmlns,attr"`
+		Keys    []graphMLKey `xml:"key"`
+		Graph   graphMLGraph `xml:"graph"`
+	}
+
+	graphMLKey struct {
+		ID      string `xml:"id,attr"`
+		For     string `xml:"for,attr"`
+		Name    string `xml:"attr.name,attr"`
+		Type    string `xml:"attr.type,attr"`
+		Default string `xml:"default,omitempty"`
+	}
+
+	graphMLGraph struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	}
+
+	graphMLNode struct {
+		ID   string        `xml:"id,attr"`
+		Data []graphMLData `xml:"data"`
+	}
+
+	graphMLEdge struct {
+		Source string        `xml:"source,attr"`
+		Target string        `xml:"target,attr"`
+		Data   []graphMLData `xml:"data"`
+	}
+
+	graphMLData struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+// WriteGraphML writes the graph in the GraphML format.
+//
+// Nodes have "label", "kind", "folder", and "tags" attributes,
+// with tags separated by spaces.
+// Edges have "embed" and "fragment" attributes.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "kind", For: "node", Name: "kind", Type: "string"},
+			{ID: "folder", For: "node", Name: "folder", Type: "string"},
+			{ID: "tags", For: "node", Name: "tags", Type: "string"},
+			{ID: "embed", For: "edge", Name: "embed", Type: "boolean", Default: "false"},
+			{ID: "fragment", For: "edge", Name: "fragment", Type: "string"},
+		},
+		Graph: graphMLGraph{
+			ID:          "wikilinks",
+			EdgeDefault: "directed",
+			Nodes:       make([]graphMLNode, len(g.Nodes)),
+			Edges:       make([]graphMLEdge, len(g.Edges)),
+		},
+	}
+
+	for i, n := range g.Nodes {
+		data := []graphMLData{
+			{Key: "label", Value: n.Label},
+			{Key: "kind", Value: n.Kind.String()},
+		}
+		if folder := n.Folder(); folder != "" {
+			data = append(data, graphMLData{Key: "folder", Value: folder})
+		}
+		if len(n.Tags) > 0 {
+			data = append(data, graphMLData{Key: "tags", Value: strings.Join(n.Tags, " ")})
+		}
+		doc.Graph.Nodes[i] = graphMLNode{ID: n.ID, Data: data}
+	}
+
+	for i, e := range g.Edges {
+		var data []graphMLData
+		if e.Embed {
+			data = append(data, graphMLData{Key: "embed", Value: strconv.FormatBool(e.Embed)})
+		}
+		if e.Fragment != "" {
+			data = append(data, graphMLData{Key: "fragment", Value: e.Fragment})
+		}
+		doc.Graph.Edges[i] = graphMLEdge{Source: e.From, Target: e.To, Data: data}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
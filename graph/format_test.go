@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testGraph() *Graph {
+	return &Graph{
+		Nodes: []*Node{
+			{ID: "Foo.md", Label: "Foo", Kind: Page, Tags: []string{"a", "b/c"}},
+			{ID: `notes/"Bar".md`, Label: `"Bar"`, Kind: Page},
+			{ID: "img.png", Label: "img.png", Kind: Attachment},
+			{ID: "Nope", Label: "Nope", Kind: Missing},
+		},
+		Edges: []*Edge{
+			{From: "Foo.md", To: `notes/"Bar".md`, Fragment: "Baz"},
+			{From: "Foo.md", To: "img.png", Embed: true},
+			{From: `notes/"Bar".md`, To: "Nope"},
+		},
+	}
+}
+
+func TestGraph_WriteDOT(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, testGraph().WriteDOT(&buf))
+	assert.Equal(t, `digraph wikilinks {
+	"Foo.md" [label="Foo"];
+	"notes/\"Bar\".md" [label="\"Bar\""];
+	"img.png" [label="img.png", shape=box];
+	"Nope" [label="Nope", style=dashed];
+	"Foo.md" -> "notes/\"Bar\".md" [label="Baz"];
+	"Foo.md" -> "img.png" [style=dashed];
+	"notes/\"Bar\".md" -> "Nope";
+}
+`, buf.String())
+}
+
+func TestDOTQuote(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `"foo"`, dotQuote("foo"))
+	assert.Equal(t, `"a\\b\"c"`, dotQuote(`a\b"c`))
+	assert.Equal(t, `"a\nb"`, dotQuote("a\r\nb"))
+}
+
+func TestGraph_WriteJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, testGraph().WriteJSON(&buf))
+	assert.JSONEq(t, `{
+		"nodes": [
+			{"id": "Foo.md", "label": "Foo", "kind": "page", "group": "", "tags": ["a", "b/c"]},
+			{"id": "notes/\"Bar\".md", "label": "\"Bar\"", "kind": "page", "group": "notes"},
+			{"id": "img.png", "label": "img.png", "kind": "attachment", "group": ""},
+			{"id": "Nope", "label": "Nope", "kind": "missing", "group": ""}
+		],
+		"links": [
+			{"source": "Foo.md", "target": "notes/\"Bar\".md", "fragment": "Baz"},
+			{"source": "Foo.md", "target": "img.png", "embed": true},
+			{"source": "notes/\"Bar\".md", "target": "Nope"}
+		]
+	}`, buf.String())
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		require.NoError(t, new(Graph).WriteJSON(&buf))
+
+		var got map[string][]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+		assert.Equal(t, map[string][]any{"nodes": {}, "links": {}}, got,
+			"d3 requires arrays, not nulls")
+	})
+}
+
+func TestGraph_WriteGraphML(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, testGraph().WriteGraphML(&buf))
+
+	var got struct {
+		Keys []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"key"`
+		Graph struct {
+			Directed string `xml:"edgedefault,attr"`
+			Nodes    []struct {
+				ID   string        `xml:"id,attr"`
+				Data []graphMLData `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string        `xml:"source,attr"`
+				Target string        `xml:"target,attr"`
+				Data   []graphMLData `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &got), "output:\n%s", buf.String())
+
+	assert.Len(t, got.Keys, 6)
+	assert.Equal(t, "directed", got.Graph.Directed)
+
+	require.Len(t, got.Graph.Nodes, 4)
+	assert.Equal(t, "Foo.md", got.Graph.Nodes[0].ID)
+	assert.Equal(t, []graphMLData{
+		{Key: "label", Value: "Foo"},
+		{Key: "kind", Value: "page"},
+		{Key: "tags", Value: "a b/c"},
+	}, got.Graph.Nodes[0].Data)
+	assert.Equal(t, `notes/"Bar".md`, got.Graph.Nodes[1].ID)
+	assert.Equal(t, []graphMLData{
+		{Key: "label", Value: `"Bar"`},
+		{Key: "kind", Value: "page"},
+		{Key: "folder", Value: "notes"},
+	}, got.Graph.Nodes[1].Data)
+
+	require.Len(t, got.Graph.Edges, 3)
+	assert.Equal(t, "Foo.md", got.Graph.Edges[0].Source)
+	assert.Equal(t, `notes/"Bar".md`, got.Graph.Edges[0].Target)
+	assert.Equal(t, []graphMLData{{Key: "fragment", Value: "Baz"}}, got.Graph.Edges[0].Data)
+	assert.Equal(t, []graphMLData{{Key: "embed", Value: "true"}}, got.Graph.Edges[1].Data)
+	assert.Empty(t, got.Graph.Edges[2].Data)
+}
@@ -0,0 +1,183 @@
+// Package graph builds graphs of the wikilinks between Markdown notes
+// and exports them for visualization.
+//
+// Use Load to build a Graph from a directory of notes,
+// optionally narrow it down with Filter,
+// and write it in one of the supported formats:
+//
+//   - Graphviz DOT with WriteDOT
+//   - JSON for d3-force with WriteJSON
+//   - GraphML with WriteGraphML
+package graph
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Kind specifies what a Node in the graph represents.
+type Kind int
+
+const (
+	// Page is a Markdown note.
+	Page Kind = iota
+
+	// Attachment is a file other than a note, like an image,
+	// that a note links to or embeds.
+	Attachment
+
+	// Missing is the target of a wikilink that does not exist.
+	Missing
+)
+
+// String returns the lowercase name of the Kind.
+func (k Kind) String() string {
+	switch k {
+	case Page:
+		return "page"
+	case Attachment:
+		return "attachment"
+	case Missing:
+		return "missing"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Graph is a directed graph of wikilinks between files.
+type Graph struct {
+	// Nodes are the files in the graph, sorted by ID.
+	Nodes []*Node
+
+	// Edges are the wikilinks between the files,
+	// in the order they appear in the notes.
+	// Wikilinks with the same source, destination, kind, and fragment
+	// are recorded once.
+	Edges []*Edge
+}
+
+// Node is a file in the graph.
+type Node struct {
+	// ID uniquely identifies the Node.
+	// For pages and attachments, this is the path of the file
+	// relative to the root of the corpus.
+	// For missing files, this is the target of the wikilink.
+	ID string
+
+	// Label is a human-readable name for the Node.
+	// This is the name of the file without the directory,
+	// or the ".md" extension for pages.
+	Label string
+
+	// Kind specifies what the Node represents.
+	Kind Kind
+
+	// Tags lists the tags of a page,
+	// specified in its front matter or inline as #tag.
+	Tags []string
+}
+
+// Folder returns the directory containing the file,
+// or an empty string for files at the root and missing files.
+func (n *Node) Folder() string {
+	if n.Kind == Missing {
+		return ""
+	}
+	if dir := path.Dir(n.ID); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// Edge is a wikilink from one file to another.
+type Edge struct {
+	// From and To are the IDs of the Nodes that the wikilink is between.
+	From, To string
+
+	// Embed is true for embeds (![[...]]) and false for links ([[...]]).
+	Embed bool
+
+	// Fragment is the portion of the target after "#", if any.
+	//
+	//	[[Foo#Bar]]  // Fragment: "Bar"
+	Fragment string
+}
+
+// Filter specifies which pages to keep in a graph.
+// Attachments and missing files are kept
+// if a page that is kept links to them.
+//
+// The zero value keeps all pages.
+type Filter struct {
+	// Folders keeps only the pages inside these directories.
+	// Subdirectories are included.
+	Folders []string
+
+	// Tags keeps only pages that have at least one of these tags.
+	// Tags are matched case-insensitively,
+	// and nested tags match their parents:
+	// a filter for "project" matches "#project/alpha".
+	Tags []string
+}
+
+// Filter returns a copy of the graph with only the pages
+// selected by the filter.
+func (g *Graph) Filter(f Filter) *Graph {
+	kinds := make(map[string]Kind, len(g.Nodes))
+	keep := make(map[string]bool)
+	for _, n := range g.Nodes {
+		kinds[n.ID] = n.Kind
+		if n.Kind == Page && f.matches(n) {
+			keep[n.ID] = true
+		}
+	}
+
+	var out Graph
+	for _, e := range g.Edges {
+		if !keep[e.From] {
+			continue
+		}
+
+		if kinds[e.To] != Page {
+			keep[e.To] = true
+		}
+		if keep[e.To] {
+			out.Edges = append(out.Edges, e)
+		}
+	}
+
+	for _, n := range g.Nodes {
+		if keep[n.ID] {
+			out.Nodes = append(out.Nodes, n)
+		}
+	}
+	return &out
+}
+
+func (f *Filter) matches(n *Node) bool {
+	return (len(f.Folders) == 0 || slices.ContainsFunc(f.Folders, n.inFolder)) &&
+		(len(f.Tags) == 0 || slices.ContainsFunc(f.Tags, n.hasTag))
+}
+
+// inFolder reports whether the node is inside the provided directory.
+func (n *Node) inFolder(dir string) bool {
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+	return dir == "" || strings.HasPrefix(n.ID, dir+"/")
+}
+
+// hasTag reports whether the node has the provided tag
+// or a tag nested under it.
+func (n *Node) hasTag(want string) bool {
+	want = strings.TrimPrefix(want, "#")
+	for _, tag := range n.Tags {
+		if len(tag) > len(want) && tag[len(want)] == '/' {
+			tag = tag[:len(want)]
+		}
+		if strings.EqualFold(tag, want) {
+			return true
+		}
+	}
+	return false
+}
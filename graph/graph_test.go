@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph_Filter(t *testing.T) {
+	t.Parallel()
+
+	g, err := Load(testCorpus())
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc      string
+		give      Filter
+		wantNodes []string
+		wantEdges [][2]string
+	}{
+		{
+			desc:      "all",
+			wantNodes: []string{"Home.md", "Missing", "images/photo.png", "notes/Bar.md", "notes/Foo.md"},
+			wantEdges: [][2]string{
+				{"Home.md", "notes/Foo.md"},
+				{"Home.md", "notes/Foo.md"},
+				{"Home.md", "Missing"},
+				{"Home.md", "images/photo.png"},
+				{"notes/Bar.md", "notes/Foo.md"},
+				{"notes/Foo.md", "Home.md"},
+			},
+		},
+		{
+			desc:      "folder",
+			give:      Filter{Folders: []string{"notes/"}},
+			wantNodes: []string{"notes/Bar.md", "notes/Foo.md"},
+			wantEdges: [][2]string{
+				{"notes/Bar.md", "notes/Foo.md"},
+			},
+		},
+		{
+			desc:      "root folder",
+			give:      Filter{Folders: []string{"."}},
+			wantNodes: []string{"Home.md", "Missing", "images/photo.png", "notes/Bar.md", "notes/Foo.md"},
+			wantEdges: [][2]string{
+				{"Home.md", "notes/Foo.md"},
+				{"Home.md", "notes/Foo.md"},
+				{"Home.md", "Missing"},
+				{"Home.md", "images/photo.png"},
+				{"notes/Bar.md", "notes/Foo.md"},
+				{"notes/Foo.md", "Home.md"},
+			},
+		},
+		{
+			desc:      "tag",
+			give:      Filter{Tags: []string{"#Draft"}},
+			wantNodes: []string{"notes/Bar.md", "notes/Foo.md"},
+			wantEdges: [][2]string{
+				{"notes/Bar.md", "notes/Foo.md"},
+			},
+		},
+		{
+			desc:      "parent tag",
+			give:      Filter{Tags: []string{"project"}},
+			wantNodes: []string{"Home.md", "Missing", "images/photo.png"},
+			wantEdges: [][2]string{
+				{"Home.md", "Missing"},
+				{"Home.md", "images/photo.png"},
+			},
+		},
+		{
+			desc: "folder and tag",
+			give: Filter{
+				Folders: []string{"notes"},
+				Tags:    []string{"reading", "index"},
+			},
+			wantNodes: []string{"notes/Bar.md"},
+		},
+		{
+			desc: "no match",
+			give: Filter{Tags: []string{"proj"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := g.Filter(tt.give)
+
+			var nodes []string
+			for _, n := range got.Nodes {
+				nodes = append(nodes, n.ID)
+			}
+			assert.Equal(t, tt.wantNodes, nodes, "nodes")
+
+			var edges [][2]string
+			for _, e := range got.Edges {
+				edges = append(edges, [2]string{e.From, e.To})
+			}
+			assert.Equal(t, tt.wantEdges, edges, "edges")
+		})
+	}
+}
+
+func TestNode_Folder(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", (&Node{ID: "Foo.md"}).Folder())
+	assert.Equal(t, "a/b", (&Node{ID: "a/b/Foo.md"}).Folder())
+	assert.Equal(t, "", (&Node{ID: "a/b", Kind: Missing}).Folder())
+}
+
+func TestKind_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "page", Page.String())
+	assert.Equal(t, "attachment", Attachment.String())
+	assert.Equal(t, "missing", Missing.String())
+	assert.Equal(t, "Kind(42)", Kind(42).String())
+}
@@ -0,0 +1,282 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"go.abhg.dev/goldmark/wikilink"
+	"go.abhg.dev/goldmark/wikilink/internal/vault"
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds a graph of the wikilinks between the files in fsys.
+//
+// Markdown files (".md" and ".markdown") are pages,
+// and all other files are attachments.
+// Hidden files and directories, like ".obsidian", are ignored.
+// Wikilinks are resolved the same way as Obsidian:
+// [[Foo]] links to the closest file named Foo.md.
+//
+// Only attachments that are linked to are included in the graph.
+// Wikilinks to files that don't exist are included
+// as edges to Missing nodes.
+// Wikilinks to headings on the same page, like [[#Foo]], are ignored.
+func Load(fsys fs.FS) (*Graph, error) {
+	v, err := vault.Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser.NewParser(
+		parser.WithBlockParsers(parser.DefaultBlockParsers()...),
+		parser.WithInlineParsers(append(
+			parser.DefaultInlineParsers(),
+			util.Prioritized(&wikilink.Parser{}, 199),
+		)...),
+		parser.WithParagraphTransformers(parser.DefaultParagraphTransformers()...),
+	)
+
+	var (
+		g     Graph
+		nodes = make(map[string]*Node)
+		edges = make(map[Edge]struct{})
+	)
+	for _, page := range v.Pages() {
+		src, err := v.ReadFile(page)
+		if err != nil {
+			return nil, err
+		}
+
+		tags, body, err := frontMatterTags(src)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", page, err)
+		}
+
+		n := &Node{
+			ID:    page,
+			Label: vault.Name(page),
+			Kind:  Page,
+		}
+		nodes[page] = n
+		g.Nodes = append(g.Nodes, n)
+
+		doc := p.Parse(text.NewReader(body))
+		_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+			if !entering {
+				return ast.WalkContinue, nil
+			}
+
+			switch node := node.(type) {
+			case *ast.CodeSpan, *ast.Link, *ast.AutoLink, *ast.RawHTML:
+				return ast.WalkSkipChildren, nil
+
+			case *ast.Text:
+				tags = append(tags, inlineTags(body, node)...)
+
+			case *wikilink.Node:
+				if len(node.Target) == 0 {
+					return ast.WalkSkipChildren, nil
+				}
+
+				e := Edge{
+					From:     page,
+					Embed:    node.Embed,
+					Fragment: string(node.Fragment),
+				}
+				if to, ok := v.Lookup(string(node.Target)); ok {
+					e.To = to
+					if _, ok := nodes[to]; !ok && !vault.IsPage(to) {
+						nodes[to] = &Node{ID: to, Label: vault.Name(to), Kind: Attachment}
+					}
+				} else {
+					e.To = string(node.Target)
+					if _, ok := nodes[e.To]; !ok {
+						nodes[e.To] = &Node{ID: e.To, Label: e.To, Kind: Missing}
+					}
+				}
+
+				if _, ok := edges[e]; !ok {
+					edges[e] = struct{}{}
+					g.Edges = append(g.Edges, &e)
+				}
+				return ast.WalkSkipChildren, nil
+			}
+			return ast.WalkContinue, nil
+		})
+
+		n.Tags = dedupe(tags)
+	}
+
+	// Attachment and missing nodes were only recorded in the map.
+	for _, n := range nodes {
+		if n.Kind != Page {
+			g.Nodes = append(g.Nodes, n)
+		}
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+
+	return &g, nil
+}
+
+// frontMatterTags parses the YAML front matter at the start of a page,
+// if any, and returns the tags listed in it
+// and the rest of the page.
+//
+// Tags may be specified as a list or a string
+// separated by commas or spaces.
+//
+//	---
+//	tags: [foo, bar]
+//	---
+func frontMatterTags(src []byte) (tags []string, body []byte, err error) {
+	fm, body, ok := splitFrontMatter(src)
+	if !ok {
+		return nil, src, nil
+	}
+
+	var meta struct {
+		Tags yaml.Node `yaml:"tags"`
+	}
+	if err := yaml.Unmarshal(fm, &meta); err != nil {
+		return nil, nil, fmt.Errorf("front matter: %w", err)
+	}
+
+	switch meta.Tags.Kind {
+	case yaml.ScalarNode:
+		tags = strings.FieldsFunc(meta.Tags.Value, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+	case yaml.SequenceNode:
+		for _, item := range meta.Tags.Content {
+			if item.Kind == yaml.ScalarNode {
+				tags = append(tags, item.Value)
+			}
+		}
+	}
+
+	for i, tag := range tags {
+		tags[i] = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	}
+	return tags, body, nil
+}
+
+// splitFrontMatter splits a page into its front matter and body
+// if it starts with a "---" line followed later by a "---" line.
+func splitFrontMatter(src []byte) (fm, body []byte, ok bool) {
+	rest, ok := cutLine(src, "---")
+	if !ok {
+		return nil, src, false
+	}
+
+	for i := 0; i < len(rest); {
+		line := rest[i:]
+		if after, ok := cutLine(line, "---"); ok {
+			return rest[:i], after, true
+		}
+		if after, ok := cutLine(line, "..."); ok {
+			return rest[:i], after, true
+		}
+
+		next := bytes.IndexByte(line, '\n')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return nil, src, false
+}
+
+// cutLine reports whether src starts with a line holding only the provided
+// text, and returns the remainder after that line.
+func cutLine(src []byte, line string) (rest []byte, ok bool) {
+	rest, ok = bytes.CutPrefix(src, []byte(line))
+	if !ok {
+		return nil, false
+	}
+
+	rest = bytes.TrimLeft(rest, " \t")
+	switch {
+	case len(rest) == 0:
+		return rest, true
+	case rest[0] == '\n':
+		return rest[1:], true
+	case bytes.HasPrefix(rest, []byte("\r\n")):
+		return rest[2:], true
+	default:
+		return nil, false
+	}
+}
+
+// inlineTags returns the #tags in the provided text node.
+// A tag starts with "#" at the beginning of a word,
+// is made of letters, digits, "_", "-", and "/",
+// and isn't only digits.
+func inlineTags(src []byte, node *ast.Text) []string {
+	seg := node.Segment
+	value := seg.Value(src)
+
+	var tags []string
+	for i := 0; i < len(value); i++ {
+		if value[i] != '#' {
+			continue
+		}
+
+		// The "#" must start a word.
+		if i > 0 {
+			if r, _ := utf8.DecodeLastRune(value[:i]); !unicode.IsSpace(r) {
+				continue
+			}
+		} else if seg.Start > 0 {
+			if r, _ := utf8.DecodeLastRune(src[:seg.Start]); !unicode.IsSpace(r) {
+				continue
+			}
+		}
+
+		end := i + 1
+		digits := true
+		for end < len(value) {
+			r, size := utf8.DecodeRune(value[end:])
+			if !isTagRune(r) {
+				break
+			}
+			digits = digits && unicode.IsDigit(r)
+			end += size
+		}
+		if end > i+1 && !digits {
+			tags = append(tags, string(value[i+1:end]))
+		}
+		i = end - 1
+	}
+	return tags
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '/'
+}
+
+// dedupe removes duplicate tags, ignoring case,
+// and keeps the first spelling of each.
+func dedupe(tags []string) []string {
+	var out []string
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		k := strings.ToLower(tag)
+		if _, ok := seen[k]; ok || tag == "" {
+			continue
+		}
+		seen[k] = struct{}{}
+		out = append(out, tag)
+	}
+	return out
+}
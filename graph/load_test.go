@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCorpus() fstest.MapFS {
+	return fstest.MapFS{
+		"Home.md": {Data: []byte(
+			"---\ntags: [index, Project/Alpha]\n---\n" +
+				"# Home\n\n" +
+				"See [[Foo]], [[Foo#Details|details]], and [[Missing]].\n" +
+				"Also [[Foo]] again and [[#Local heading]].\n\n" +
+				"![[photo.png]]\n",
+		)},
+		"notes/Foo.md": {Data: []byte(
+			"Back to [[Home]]. #draft #123 issue#4\n\n" +
+				"`[[Code]] #code` and [link #text](https://example.com/#frag)\n\n" +
+				"    [[Indented code]]\n",
+		)},
+		"notes/Bar.md": {Data: []byte(
+			"---\ntags: reading, draft\n---\n[[notes/Foo]]\n",
+		)},
+		"images/photo.png":  {Data: []byte("png")},
+		"images/unused.png": {Data: []byte("png")},
+		".obsidian/app.json": {
+			Data: []byte("{}"),
+		},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	g, err := Load(testCorpus())
+	require.NoError(t, err)
+
+	assert.Equal(t, []*Node{
+		{ID: "Home.md", Label: "Home", Kind: Page, Tags: []string{"index", "Project/Alpha"}},
+		{ID: "Missing", Label: "Missing", Kind: Missing},
+		{ID: "images/photo.png", Label: "photo.png", Kind: Attachment},
+		{ID: "notes/Bar.md", Label: "Bar", Kind: Page, Tags: []string{"reading", "draft"}},
+		{ID: "notes/Foo.md", Label: "Foo", Kind: Page, Tags: []string{"draft"}},
+	}, g.Nodes)
+
+	assert.Equal(t, []*Edge{
+		{From: "Home.md", To: "notes/Foo.md"},
+		{From: "Home.md", To: "notes/Foo.md", Fragment: "Details"},
+		{From: "Home.md", To: "Missing"},
+		{From: "Home.md", To: "images/photo.png", Embed: true},
+		{From: "notes/Bar.md", To: "notes/Foo.md"},
+		{From: "notes/Foo.md", To: "Home.md"},
+	}, g.Edges)
+}
+
+func TestFrontMatterTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		give     string
+		wantTags []string
+		wantBody string
+	}{
+		{desc: "none", give: "hello", wantBody: "hello"},
+		{
+			desc:     "list",
+			give:     "---\ntags:\n  - foo\n  - '#bar'\n---\nhello",
+			wantTags: []string{"foo", "bar"},
+			wantBody: "hello",
+		},
+		{
+			desc:     "string",
+			give:     "---\ntags: foo, bar baz\n...\nhello",
+			wantTags: []string{"foo", "bar", "baz"},
+			wantBody: "hello",
+		},
+		{
+			desc:     "no tags",
+			give:     "---\ntitle: Foo\n---\r\nhello",
+			wantBody: "hello",
+		},
+		{
+			desc:     "unterminated",
+			give:     "---\nhello",
+			wantBody: "---\nhello",
+		},
+		{
+			desc:     "thematic break",
+			give:     "--- not front matter\n---\n",
+			wantBody: "--- not front matter\n---\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			tags, body, err := frontMatterTags([]byte(tt.give))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTags, tags)
+			assert.Equal(t, tt.wantBody, string(body))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := frontMatterTags([]byte("---\ntags: [\n---\n"))
+		assert.ErrorContains(t, err, "front matter")
+	})
+}
@@ -0,0 +1,179 @@
+package wikilink
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// HierarchySeparator separates the levels of hierarchical page names
+// used by HierarchyResolver.
+//
+//	project.design.api
+const HierarchySeparator = "."
+
+// DefaultHierarchyResolver is a HierarchyResolver
+// that adds ".html" to the end of page targets.
+//
+//	[[project.design.api]]  // => "project.design.api.html"
+//	[[project.diagram.png]] // => "project.diagram.png"
+var DefaultHierarchyResolver = &HierarchyResolver{
+	PathResolver: PathResolver{PageSuffix: ".html"},
+}
+
+// HierarchyResolver resolves wikilinks to pages with hierarchical names
+// separated by dots, as used by Dendron and Foam.
+//
+// It is a PathResolver with a different default for attachments:
+// unlike DefaultResolver, it doesn't treat the last part of a name
+// as a file extension unless it's a known attachment extension.
+//
+//	&HierarchyResolver{
+//		PathResolver: PathResolver{PageSuffix: ".html"},
+//	}
+//	// [[project.design.api]]  => "project.design.api.html"
+//	// [[project.diagram.png]] => "project.diagram.png"
+//
+// Use DefaultHierarchyResolver for the above.
+//
+// Use Breadcrumbs, HierarchyParent, and HierarchyChildren
+// to render navigation for a page in the hierarchy.
+type HierarchyResolver struct {
+	// PathResolver specifies how destinations are built from targets.
+	//
+	// AttachmentExtensions defaults to DefaultAttachmentExtensions()
+	// if unspecified.
+	PathResolver
+}
+
+var _ Resolver = (*HierarchyResolver)(nil)
+
+// ResolveWikilink returns the destination for the provided wikilink.
+func (r *HierarchyResolver) ResolveWikilink(n *Node) ([]byte, error) {
+	return r.pathResolver().ResolveWikilink(n)
+}
+
+// pathResolver returns the PathResolver with defaults filled in.
+func (r *HierarchyResolver) pathResolver() *PathResolver {
+	pr := r.PathResolver
+	if pr.AttachmentExtensions == nil {
+		pr.AttachmentExtensions = _attachmentExtensions
+	}
+	return &pr
+}
+
+// Breadcrumb is a level of the hierarchy above or at a page.
+type Breadcrumb struct {
+	// Label is the last part of the name for this level.
+	Label string
+
+	// Target is the full name of the page for this level.
+	Target string
+
+	// Destination is the resolved destination for Target.
+	Destination string
+}
+
+// Breadcrumbs returns the levels of the hierarchy for the provided page,
+// starting at the top.
+// The last breadcrumb is the page itself.
+//
+//	r.Breadcrumbs("project.design.api")
+//	// => project (project.html)
+//	//    design  (project.design.html)
+//	//    api     (project.design.api.html)
+//
+// The extension of an attachment is part of its own level.
+//
+//	r.Breadcrumbs("project.diagram.png")
+//	// => project     (project.html)
+//	//    diagram.png (project.diagram.png)
+func (r *HierarchyResolver) Breadcrumbs(page string) []Breadcrumb {
+	pr := r.pathResolver()
+	name, ext := page, ""
+	if pr.isAttachment([]byte(page)) {
+		ext = path.Ext(page)
+		name = strings.TrimSuffix(page, ext)
+	}
+	if name == "" {
+		return nil
+	}
+
+	parts := strings.Split(name, HierarchySeparator)
+	crumbs := make([]Breadcrumb, 0, len(parts))
+	var end int
+	for i, part := range parts {
+		end += len(part)
+		if i > 0 {
+			end += len(HierarchySeparator)
+		}
+		if part == "" {
+			continue // "a..b"
+		}
+
+		target := name[:end]
+		if i == len(parts)-1 {
+			target = page
+			part += ext
+		}
+		dest, _ := pr.ResolveWikilink(&Node{Target: []byte(target)})
+		crumbs = append(crumbs, Breadcrumb{
+			Label:       part,
+			Target:      target,
+			Destination: string(dest),
+		})
+	}
+	return crumbs
+}
+
+// HierarchyParent returns the name of the parent of a hierarchical page
+// or false if the page is at the top of the hierarchy.
+//
+//	HierarchyParent("project.design.api") // => "project.design", true
+//	HierarchyParent("project")            // => "", false
+func HierarchyParent(page string) (string, bool) {
+	idx := strings.LastIndex(page, HierarchySeparator)
+	if idx <= 0 {
+		return "", false
+	}
+	return page[:idx], true
+}
+
+// HierarchyChildren returns the names of the direct children of a page
+// in a hierarchy of pages with the provided names, sorted by name.
+// The names must not include attachments.
+// Use an empty parent to get the pages at the top of the hierarchy.
+//
+// Children without a page of their own are included
+// if they have descendants with pages.
+// For example, given only "project.design.api",
+// the children of "project" are ["project.design"].
+func HierarchyChildren(parent string, pages []string) []string {
+	prefix := parent
+	if prefix != "" {
+		prefix += HierarchySeparator
+	}
+
+	seen := make(map[string]struct{})
+	var children []string
+	for _, p := range pages {
+		rest, ok := strings.CutPrefix(p, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		if idx := strings.Index(rest, HierarchySeparator); idx >= 0 {
+			rest = rest[:idx]
+		}
+		if rest == "" {
+			continue
+		}
+
+		child := prefix + rest
+		if _, ok := seen[child]; !ok {
+			seen[child] = struct{}{}
+			children = append(children, child)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
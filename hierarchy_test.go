@@ -0,0 +1,203 @@
+package wikilink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHierarchyResolver(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		resolver *HierarchyResolver
+		target   string
+		fragment string
+		want     string
+	}{
+		{
+			desc:   "page",
+			target: "project.design.api",
+			want:   "project.design.api.html",
+		},
+		{
+			desc:   "top level",
+			target: "project",
+			want:   "project.html",
+		},
+		{
+			desc:   "version number",
+			target: "v1.2 release",
+			want:   "v1.2 release.html",
+		},
+		{
+			desc:   "attachment",
+			target: "project.diagram.png",
+			want:   "project.diagram.png",
+		},
+		{
+			desc:   "attachment case",
+			target: "Scan.PDF",
+			want:   "Scan.PDF",
+		},
+		{
+			desc:     "fragment",
+			target:   "project.design",
+			fragment: "goals",
+			want:     "project.design.html#goals",
+		},
+		{
+			desc:     "fragment only",
+			fragment: "goals",
+			want:     "#goals",
+		},
+		{
+			desc:     "custom extensions",
+			resolver: &HierarchyResolver{PathResolver{PageSuffix: ".html", AttachmentExtensions: []string{".drawio"}}},
+			target:   "project.arch.drawio",
+			want:     "project.arch.drawio",
+		},
+		{
+			desc:     "base path and suffix",
+			resolver: &HierarchyResolver{PathResolver{BasePath: "/notes/", PageSuffix: "/"}},
+			target:   "project.design",
+			fragment: "goals",
+			want:     "/notes/project.design/#goals",
+		},
+		{
+			desc:     "no suffix",
+			resolver: &HierarchyResolver{},
+			target:   "project.design",
+			want:     "project.design",
+		},
+		{
+			desc:     "base path attachment",
+			resolver: &HierarchyResolver{PathResolver{BasePath: "/notes/"}},
+			target:   "project.diagram.png",
+			want:     "/notes/project.diagram.png",
+		},
+		{
+			desc:     "custom extensions replace defaults",
+			resolver: &HierarchyResolver{PathResolver{PageSuffix: ".html", AttachmentExtensions: []string{".drawio"}}},
+			target:   "project.png",
+			want:     "project.png.html",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			r := tt.resolver
+			if r == nil {
+				r = DefaultHierarchyResolver
+			}
+
+			got, err := r.ResolveWikilink(&Node{
+				Target:   []byte(tt.target),
+				Fragment: []byte(tt.fragment),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestHierarchyResolver_Breadcrumbs(t *testing.T) {
+	t.Parallel()
+
+	r := DefaultHierarchyResolver
+	tests := []struct {
+		give string
+		want []Breadcrumb
+	}{
+		{give: ""},
+		{
+			give: "project",
+			want: []Breadcrumb{
+				{Label: "project", Target: "project", Destination: "project.html"},
+			},
+		},
+		{
+			give: "project.design.api",
+			want: []Breadcrumb{
+				{Label: "project", Target: "project", Destination: "project.html"},
+				{Label: "design", Target: "project.design", Destination: "project.design.html"},
+				{Label: "api", Target: "project.design.api", Destination: "project.design.api.html"},
+			},
+		},
+		{
+			give: "project..api",
+			want: []Breadcrumb{
+				{Label: "project", Target: "project", Destination: "project.html"},
+				{Label: "api", Target: "project..api", Destination: "project..api.html"},
+			},
+		},
+		{
+			give: "project.diagram.png",
+			want: []Breadcrumb{
+				{Label: "project", Target: "project", Destination: "project.html"},
+				{Label: "diagram.png", Target: "project.diagram.png", Destination: "project.diagram.png"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, r.Breadcrumbs(tt.give), "Breadcrumbs(%q)", tt.give)
+	}
+}
+
+func TestHierarchyParent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give   string
+		want   string
+		wantOK bool
+	}{
+		{give: "project.design.api", want: "project.design", wantOK: true},
+		{give: "project.design", want: "project", wantOK: true},
+		{give: "project"},
+		{give: ".hidden"},
+		{give: ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := HierarchyParent(tt.give)
+		assert.Equal(t, tt.wantOK, ok, "HierarchyParent(%q)", tt.give)
+		assert.Equal(t, tt.want, got, "HierarchyParent(%q)", tt.give)
+	}
+}
+
+func TestHierarchyChildren(t *testing.T) {
+	t.Parallel()
+
+	pages := []string{
+		"project",
+		"project.design",
+		"project.design.api",
+		"project.design.ui",
+		"project.roadmap.2024.q1",
+		"projects",
+		"ideas.foo",
+	}
+
+	tests := []struct {
+		parent string
+		want   []string
+	}{
+		{parent: "", want: []string{"ideas", "project", "projects"}},
+		{parent: "project", want: []string{"project.design", "project.roadmap"}},
+		{parent: "project.design", want: []string{"project.design.api", "project.design.ui"}},
+		{parent: "project.roadmap", want: []string{"project.roadmap.2024"}},
+		{parent: "project.design.api"},
+		{parent: "missing"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, HierarchyChildren(tt.parent, pages), "HierarchyChildren(%q)", tt.parent)
+	}
+}
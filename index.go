@@ -0,0 +1,347 @@
+package wikilink
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IndexedPage describes a page that wikilinks may refer to.
+type IndexedPage struct {
+	// Name is the target used to link to the page.
+	// For example, "Foo" for [[Foo]].
+	Name string
+
+	// Aliases are other names for the page.
+	// Suggestions matched by an alias still link to Name.
+	Aliases []string
+
+	// Headings are the headings in the page
+	// that may be linked to with [[Name#Heading]].
+	Headings []string
+}
+
+// Suggestion is a wikilink target suggested by PageIndex.
+type Suggestion struct {
+	// Target is the name of the suggested page.
+	Target string
+
+	// Fragment is the suggested heading in the page,
+	// if the query included a fragment.
+	Fragment string
+
+	// Match is the text that matched the query:
+	// the page name, one of its aliases, or a heading.
+	Match string
+
+	// Score ranks the suggestion against others for the same query.
+	// Higher scores are better matches.
+	Score int
+}
+
+// String returns the suggested wikilink target:
+// "Target" or "Target#Fragment".
+func (s Suggestion) String() string {
+	if s.Fragment == "" {
+		return s.Target
+	}
+	return s.Target + "#" + s.Fragment
+}
+
+// PageIndex is an in-memory index of page names, aliases, and headings
+// that suggests wikilink targets matching partial input.
+// Use it to offer completions when a user types "[[".
+//
+//	var idx wikilink.PageIndex
+//	for _, page := range pages {
+//		idx.Add(wikilink.IndexedPage{Name: page.Name, Headings: page.Headings})
+//	}
+//	idx.Suggest("foo", 10)     // => Foo, Food, Foo Bar, ...
+//	idx.Suggest("Foo#inst", 5) // => Foo#Installation, ...
+//
+// Matching is fuzzy: in order of preference, the query may match
+// a name exactly, a prefix of it, the start of a word in it,
+// any other substring of it, or a subsequence of its characters.
+// Names and queries are normalized with the same Normalizer
+// so that matching agrees with resolvers that use it.
+//
+// The zero value is an empty index using DefaultNormalizer.
+// A PageIndex is safe for concurrent use.
+type PageIndex struct {
+	// Normalizer normalizes page names, aliases, headings, and queries.
+	// It must not be changed after the first page is added.
+	//
+	// Defaults to DefaultNormalizer if unspecified.
+	Normalizer *Normalizer
+
+	mu      sync.RWMutex
+	pages   map[string]*indexEntry   // by key of name
+	aliases map[string][]*indexEntry // by key of alias, in order added
+	seq     int                      // seq of the next new page
+}
+
+// indexEntry is an IndexedPage with precomputed keys.
+type indexEntry struct {
+	page IndexedPage
+
+	// keys holds the keys of the name followed by the aliases.
+	keys []string
+
+	// headings holds the keys of the headings.
+	headings []string
+
+	// seq orders pages by when they were first added.
+	// Replacing a page keeps its place.
+	seq int
+}
+
+// Add adds a page to the index,
+// replacing any page with the same name.
+//
+// Where a name or alias belongs to more than one page,
+// names take precedence over aliases,
+// and earlier pages take precedence over later ones,
+// as with AutoLinkTransformer.
+func (idx *PageIndex) Add(page IndexedPage) {
+	nz := idx.normalizer()
+	e := &indexEntry{
+		page: page,
+		keys: make([]string, 0, 1+len(page.Aliases)),
+	}
+	e.keys = append(e.keys, nz.Key([]byte(page.Name)))
+	for _, alias := range page.Aliases {
+		e.keys = append(e.keys, nz.Key([]byte(alias)))
+	}
+	for _, h := range page.Headings {
+		e.headings = append(e.headings, nz.Key([]byte(h)))
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.pages == nil {
+		idx.pages = make(map[string]*indexEntry)
+		idx.aliases = make(map[string][]*indexEntry)
+	}
+	if old, ok := idx.pages[e.keys[0]]; ok {
+		e.seq = old.seq
+		idx.remove(e.keys[0])
+	} else {
+		e.seq = idx.seq
+		idx.seq++
+	}
+
+	idx.pages[e.keys[0]] = e
+	for _, key := range e.keys[1:] {
+		owners := idx.aliases[key]
+		if slices.Contains(owners, e) {
+			continue // duplicate alias
+		}
+		i, _ := slices.BinarySearchFunc(owners, e.seq, func(o *indexEntry, seq int) int {
+			return cmp.Compare(o.seq, seq)
+		})
+		idx.aliases[key] = slices.Insert(owners, i, e)
+	}
+}
+
+// Remove removes the page with the provided name from the index.
+// It reports whether the page was found.
+func (idx *PageIndex) Remove(name string) bool {
+	key := idx.normalizer().Key([]byte(name))
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	return idx.remove(key)
+}
+
+func (idx *PageIndex) remove(key string) bool {
+	e, ok := idx.pages[key]
+	if !ok {
+		return false
+	}
+
+	delete(idx.pages, key)
+	for _, k := range e.keys[1:] {
+		owners := slices.DeleteFunc(idx.aliases[k], func(o *indexEntry) bool {
+			return o == e
+		})
+		if len(owners) > 0 {
+			idx.aliases[k] = owners
+		} else {
+			delete(idx.aliases, k)
+		}
+	}
+	return true
+}
+
+// lookup returns the page that the provided key of a name or alias
+// refers to.
+// Names take precedence over aliases,
+// and earlier pages take precedence over later ones.
+func (idx *PageIndex) lookup(key string) (*indexEntry, bool) {
+	if e, ok := idx.pages[key]; ok {
+		return e, true
+	}
+	if owners := idx.aliases[key]; len(owners) > 0 {
+		return owners[0], true
+	}
+	return nil, false
+}
+
+// Len reports the number of pages in the index.
+func (idx *PageIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.pages)
+}
+
+// Suggest returns up to limit suggestions for a partially typed target,
+// best matches first.
+// A limit of zero or less returns all matches.
+//
+// If the query has the form "Target#partial",
+// it suggests headings in the page that Target refers to,
+// by name or by alias.
+// Queries for headings in the current page ("#partial")
+// have no suggestions because the index does not know the current page.
+//
+// An empty query matches all pages.
+func (idx *PageIndex) Suggest(query string, limit int) []Suggestion {
+	nz := idx.normalizer()
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var suggestions []Suggestion
+	if target, fragment, ok := strings.Cut(query, "#"); ok {
+		e, ok := idx.lookup(nz.Key([]byte(target)))
+		if !ok {
+			return nil
+		}
+
+		q := nz.Key([]byte(fragment))
+		for i, h := range e.headings {
+			if score, ok := fuzzyScore(h, q); ok {
+				heading := e.page.Headings[i]
+				suggestions = append(suggestions, Suggestion{
+					Target:   e.page.Name,
+					Fragment: heading,
+					Match:    heading,
+					Score:    score,
+				})
+			}
+		}
+	} else {
+		q := nz.Key([]byte(query))
+		for _, e := range idx.pages {
+			best := Suggestion{Score: -1}
+			for i, key := range e.keys {
+				// Aliases win only if they're strictly better.
+				if score, ok := fuzzyScore(key, q); ok && score > best.Score {
+					best = Suggestion{Target: e.page.Name, Match: e.page.Name, Score: score}
+					if i > 0 {
+						best.Match = e.page.Aliases[i-1]
+					}
+				}
+			}
+			if best.Score >= 0 {
+				suggestions = append(suggestions, best)
+			}
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		switch {
+		case a.Score != b.Score:
+			return a.Score > b.Score
+		case len(a.Match) != len(b.Match):
+			return len(a.Match) < len(b.Match)
+		case a.Target != b.Target:
+			return a.Target < b.Target
+		default:
+			return a.Fragment < b.Fragment
+		}
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+func (idx *PageIndex) normalizer() *Normalizer {
+	if idx.Normalizer != nil {
+		return idx.Normalizer
+	}
+	return DefaultNormalizer
+}
+
+// Scores for each kind of match in fuzzyScore.
+// Subsequence matches score below _scoreSubstring,
+// less the number of characters skipped between matches.
+const (
+	_scoreExact      = 1000
+	_scorePrefix     = 800
+	_scoreWordPrefix = 600
+	_scoreSubstring  = 400
+)
+
+// fuzzyScore reports whether the normalized query matches
+// the normalized candidate, and how well.
+func fuzzyScore(candidate, query string) (int, bool) {
+	switch {
+	case candidate == query:
+		return _scoreExact, true
+	case strings.HasPrefix(candidate, query):
+		return _scorePrefix, true
+	}
+
+	if i := strings.Index(candidate, query); i >= 0 {
+		for ; i >= 0; i = nextIndex(candidate, query, i) {
+			r, _ := utf8.DecodeLastRuneInString(candidate[:i])
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return _scoreWordPrefix, true
+			}
+		}
+		return _scoreSubstring, true
+	}
+
+	// Match the query as a subsequence of the candidate,
+	// penalizing each character skipped after the first match.
+	var skipped int
+	started := false
+	rest := query
+	for _, r := range candidate {
+		if rest == "" {
+			break
+		}
+		q, size := utf8.DecodeRuneInString(rest)
+		if r == q {
+			rest = rest[size:]
+			started = true
+		} else if started {
+			skipped++
+		}
+	}
+	if rest != "" {
+		return 0, false
+	}
+	return max(_scoreSubstring-1-skipped, 1), true
+}
+
+// nextIndex returns the index of the next occurrence of sub in s
+// after the one at index i, or -1 if there isn't one.
+func nextIndex(s, sub string, i int) int {
+	_, size := utf8.DecodeRuneInString(s[i:])
+	j := strings.Index(s[i+size:], sub)
+	if j < 0 {
+		return -1
+	}
+	return i + size + j
+}
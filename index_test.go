@@ -0,0 +1,241 @@
+package wikilink
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageIndex_Suggest(t *testing.T) {
+	t.Parallel()
+
+	var idx PageIndex
+	idx.Add(IndexedPage{Name: "Foo"})
+	idx.Add(IndexedPage{Name: "Food"})
+	idx.Add(IndexedPage{Name: "Foo Bar", Headings: []string{"Installation", "Usage", "Advanced usage"}})
+	idx.Add(IndexedPage{Name: "Bar Foo"})
+	idx.Add(IndexedPage{Name: "Tofu"})
+	idx.Add(IndexedPage{Name: "Frodo"})
+	idx.Add(IndexedPage{Name: "Meeting notes", Aliases: []string{"Minutes"}})
+	idx.Add(IndexedPage{Name: "Café"})
+
+	tests := []struct {
+		desc  string
+		query string
+		limit int
+		want  []string
+	}{
+		{
+			desc:  "ranked",
+			query: "foo",
+			want:  []string{"Foo", "Food", "Foo Bar", "Bar Foo", "Frodo"},
+		},
+		{
+			desc:  "limit",
+			query: "foo",
+			limit: 2,
+			want:  []string{"Foo", "Food"},
+		},
+		{
+			desc:  "normalized",
+			query: "  FOO_b",
+			want:  []string{"Foo Bar"},
+		},
+		{
+			desc:  "substring",
+			query: "of",
+			want:  []string{"Tofu"},
+		},
+		{
+			desc:  "alias",
+			query: "minu",
+			want:  []string{"Meeting notes"},
+		},
+		{
+			desc:  "accents",
+			query: "CAFÉ",
+			want:  []string{"Café"},
+		},
+		{
+			desc:  "no match",
+			query: "xyz",
+		},
+		{
+			desc:  "headings",
+			query: "Foo Bar#usa",
+			want:  []string{"Foo Bar#Usage", "Foo Bar#Advanced usage"},
+		},
+		{
+			desc:  "all headings",
+			query: "foo_bar#",
+			want:  []string{"Foo Bar#Usage", "Foo Bar#Installation", "Foo Bar#Advanced usage"},
+		},
+		{
+			desc:  "headings of unknown page",
+			query: "Fo#usa",
+		},
+		{
+			desc:  "headings of current page",
+			query: "#usa",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := idx.Suggest(tt.query, tt.limit)
+			assert.Equal(t, tt.want, suggestionStrings(got))
+		})
+	}
+}
+
+func TestPageIndex_Suggest_match(t *testing.T) {
+	t.Parallel()
+
+	var idx PageIndex
+	idx.Add(IndexedPage{Name: "Meeting notes", Aliases: []string{"Minutes", "Notes"}})
+
+	assert.Equal(t, []Suggestion{
+		{Target: "Meeting notes", Match: "Notes", Score: _scoreExact},
+	}, idx.Suggest("notes", 0), "alias is a better match")
+
+	assert.Equal(t, []Suggestion{
+		{Target: "Meeting notes", Match: "Meeting notes", Score: _scorePrefix},
+	}, idx.Suggest("m", 0), "name wins ties")
+}
+
+func TestPageIndex_AddRemove(t *testing.T) {
+	t.Parallel()
+
+	var idx PageIndex
+	idx.Add(IndexedPage{Name: "Foo", Headings: []string{"Old"}})
+	idx.Add(IndexedPage{Name: "Bar", Aliases: []string{"Foo", "Baz"}, Headings: []string{"Alias"}})
+	assert.Equal(t, 2, idx.Len())
+
+	assert.Equal(t, []string{"Foo#Old"}, suggestionStrings(idx.Suggest("Foo#", 0)),
+		"name takes precedence over alias")
+	assert.Equal(t, []string{"Bar#Alias"}, suggestionStrings(idx.Suggest("baz#", 0)))
+
+	idx.Add(IndexedPage{Name: "foo", Headings: []string{"New"}})
+	assert.Equal(t, 2, idx.Len(), "same key replaces page")
+	assert.Equal(t, []string{"foo#New"}, suggestionStrings(idx.Suggest("Foo#", 0)))
+
+	assert.True(t, idx.Remove("FOO"))
+	assert.False(t, idx.Remove("FOO"))
+	assert.Equal(t, 1, idx.Len())
+	assert.Equal(t, []string{"Bar#Alias"}, suggestionStrings(idx.Suggest("Foo#", 0)),
+		"alias is restored after name is removed")
+}
+
+func TestPageIndex_aliasConflicts(t *testing.T) {
+	t.Parallel()
+
+	var idx PageIndex
+	idx.Add(IndexedPage{Name: "A", Aliases: []string{"Shared"}, Headings: []string{"InA"}})
+	idx.Add(IndexedPage{Name: "B", Aliases: []string{"Shared"}, Headings: []string{"InB"}})
+	idx.Add(IndexedPage{Name: "C", Aliases: []string{"Shared", "shared"}, Headings: []string{"InC"}})
+	assert.Equal(t, []string{"A#InA"}, suggestionStrings(idx.Suggest("Shared#", 0)),
+		"earliest page wins")
+
+	idx.Add(IndexedPage{Name: "A", Aliases: []string{"Shared"}, Headings: []string{"InA2"}})
+	assert.Equal(t, []string{"A#InA2"}, suggestionStrings(idx.Suggest("Shared#", 0)),
+		"replaced page keeps its place")
+
+	// Repeat to catch nondeterminism.
+	for range 10 {
+		idx := PageIndex{}
+		for _, name := range []string{"A", "B", "C", "D"} {
+			idx.Add(IndexedPage{Name: name, Aliases: []string{"Shared"}, Headings: []string{"In" + name}})
+		}
+		require.True(t, idx.Remove("A"))
+		assert.Equal(t, []string{"B#InB"}, suggestionStrings(idx.Suggest("Shared#", 0)),
+			"next page wins after the winner is removed")
+		require.True(t, idx.Remove("C"))
+		assert.Equal(t, []string{"B#InB"}, suggestionStrings(idx.Suggest("Shared#", 0)))
+	}
+
+	require.True(t, idx.Remove("A"))
+	assert.Equal(t, []string{"B#InB"}, suggestionStrings(idx.Suggest("Shared#", 0)))
+	idx.Add(IndexedPage{Name: "Shared", Headings: []string{"Own"}})
+	assert.Equal(t, []string{"Shared#Own"}, suggestionStrings(idx.Suggest("Shared#", 0)),
+		"name wins over earlier aliases")
+	require.True(t, idx.Remove("Shared"))
+	assert.Equal(t, []string{"B#InB"}, suggestionStrings(idx.Suggest("Shared#", 0)))
+}
+
+func TestPageIndex_Normalizer(t *testing.T) {
+	t.Parallel()
+
+	idx := PageIndex{Normalizer: &Normalizer{}}
+	idx.Add(IndexedPage{Name: "Foo"})
+	idx.Add(IndexedPage{Name: "foo"})
+
+	assert.Equal(t, 2, idx.Len())
+	assert.Equal(t, []string{"foo"}, suggestionStrings(idx.Suggest("fo", 0)))
+}
+
+func TestPageIndex_concurrent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		idx PageIndex
+		wg  sync.WaitGroup
+	)
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := range 50 {
+				name := fmt.Sprintf("Page %d-%d", i, j)
+				idx.Add(IndexedPage{Name: name})
+				idx.Suggest("page", 5)
+				if j%2 == 0 {
+					idx.Remove(name)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100, idx.Len())
+}
+
+func TestFuzzyScore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		candidate, query string
+		want             int
+	}{
+		{"foo", "foo", _scoreExact},
+		{"foo bar", "foo", _scorePrefix},
+		{"bar foo", "foo", _scoreWordPrefix},
+		{"bar-foo", "foo", _scoreWordPrefix},
+		{"tofu foo", "foo", _scoreWordPrefix},
+		{"tofu", "of", _scoreSubstring},
+		{"foo bar", "fb", _scoreSubstring - 1 - 3},
+		{"frodo", "fdo", _scoreSubstring - 1 - 2},
+		{"frodo", "xyz", 0},
+		{"frodo", "oo", _scoreSubstring - 1 - 1},
+	}
+
+	for _, tt := range tests {
+		got, ok := fuzzyScore(tt.candidate, tt.query)
+		assert.Equal(t, tt.want > 0, ok, "fuzzyScore(%q, %q)", tt.candidate, tt.query)
+		assert.Equal(t, tt.want, got, "fuzzyScore(%q, %q)", tt.candidate, tt.query)
+	}
+}
+
+func suggestionStrings(suggestions []Suggestion) []string {
+	var out []string
+	for _, s := range suggestions {
+		out = append(out, s.String())
+	}
+	return out
+}
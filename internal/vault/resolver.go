@@ -0,0 +1,77 @@
+package vault
+
+import (
+	"context"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+type pageKey struct{}
+
+// WithPage returns a context for converting the page at the provided path.
+// Pass it to the conversion with wikilink.SetContext
+// so that Resolver can build destinations relative to the page.
+func WithPage(ctx context.Context, page string) context.Context {
+	return context.WithValue(ctx, pageKey{}, page)
+}
+
+// PageFrom returns the path of the page specified with WithPage,
+// or an empty string if there isn't one.
+func PageFrom(ctx context.Context) string {
+	page, _ := ctx.Value(pageKey{}).(string)
+	return page
+}
+
+// Resolver resolves wikilinks to files in a Vault.
+// Wikilinks to files that are not in the Vault are left unresolved.
+//
+// Fragments are turned into heading IDs the same way as
+// goldmark's parser.WithAutoHeadingID option.
+//
+//	[[Foo#Bar Baz]]  // => URL("", "Foo.md") + "#bar-baz"
+type Resolver struct {
+	// Vault holds the files that wikilinks may refer to.
+	Vault *Vault
+
+	// URL returns the destination for the file at path "to"
+	// in a link from the page at path "from".
+	//
+	// from is empty if the page was not specified with WithPage.
+	URL func(from, to string) string
+}
+
+var _ wikilink.ContextResolver = (*Resolver)(nil)
+
+// ResolveWikilink resolves the wikilink without a current page.
+func (r *Resolver) ResolveWikilink(n *wikilink.Node) ([]byte, error) {
+	return r.ResolveWikilinkContext(context.Background(), n)
+}
+
+// ResolveWikilinkContext resolves the wikilink from the page
+// specified with WithPage.
+func (r *Resolver) ResolveWikilinkContext(ctx context.Context, n *wikilink.Node) ([]byte, error) {
+	var dest []byte
+	if len(n.Target) > 0 {
+		to, ok := r.Vault.Lookup(string(n.Target))
+		if !ok {
+			return nil, nil
+		}
+		dest = []byte(r.URL(PageFrom(ctx), to))
+	} else if len(n.Fragment) == 0 {
+		return nil, nil
+	}
+
+	if len(n.Fragment) > 0 {
+		dest = append(dest, '#')
+		dest = append(dest, HeadingID(string(n.Fragment))...)
+	}
+	return dest, nil
+}
+
+// HeadingID returns the ID generated for a heading with the provided text
+// by goldmark's parser.WithAutoHeadingID option.
+func HeadingID(heading string) string {
+	return string(parser.NewContext().IDs().Generate([]byte(heading), ast.KindHeading))
+}
@@ -0,0 +1,185 @@
+// Package vault indexes a directory of Markdown notes,
+// like an Obsidian vault,
+// and resolves wikilinks between the files in it.
+package vault
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+// Vault is an index of the files in a directory of notes.
+//
+// Pages are Markdown files in the directory.
+// All other files are attachments.
+// Hidden files and directories, like ".obsidian", are ignored.
+type Vault struct {
+	fsys fs.FS
+
+	pages       []string // sorted
+	attachments []string // sorted
+
+	// byPath maps the canonical key of a path to the path.
+	// Pages are also indexed by their path without the extension.
+	byPath map[string]string
+
+	// byName maps the canonical key of a base name to all paths
+	// with that base name, ordered by preference.
+	// Pages are also indexed by their name without the extension.
+	byName map[string][]string
+}
+
+// Load indexes the notes in the provided file system.
+func Load(fsys fs.FS) (*Vault, error) {
+	v := Vault{
+		fsys:   fsys,
+		byPath: make(map[string]string),
+		byName: make(map[string][]string),
+	}
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		v.add(p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name, paths := range v.byName {
+		sort.Slice(paths, func(i, j int) bool {
+			return preferred(paths[i], paths[j])
+		})
+		v.byName[name] = paths
+	}
+	return &v, nil
+}
+
+func (v *Vault) add(p string) {
+	keys := []string{p}
+	if IsPage(p) {
+		v.pages = append(v.pages, p)
+		keys = append(keys, trimExt(p))
+	} else {
+		v.attachments = append(v.attachments, p)
+	}
+
+	for _, k := range keys {
+		v.byPath[key(k)] = p
+
+		name := key(path.Base(k))
+		v.byName[name] = append(v.byName[name], p)
+	}
+}
+
+// Pages returns the paths of all pages in the vault, sorted.
+func (v *Vault) Pages() []string {
+	return v.pages
+}
+
+// Attachments returns the paths of all attachments in the vault, sorted.
+func (v *Vault) Attachments() []string {
+	return v.attachments
+}
+
+// ReadFile reads the file at the provided path.
+func (v *Vault) ReadFile(p string) ([]byte, error) {
+	return fs.ReadFile(v.fsys, p)
+}
+
+// Open opens the file at the provided path.
+func (v *Vault) Open(p string) (fs.File, error) {
+	return v.fsys.Open(p)
+}
+
+// Lookup returns the path of the file that a wikilink target refers to.
+// Targets are matched with wikilink.DefaultNormalizer,
+// and the ".md" extension is optional for pages.
+//
+// Like Obsidian, targets may be the full path of the file
+// from the root of the vault, or any suffix of it.
+// If multiple files match, the one closest to the root is used.
+//
+//	[[Foo]]         // => "Foo.md" or "notes/Foo.md"
+//	[[notes/Foo]]   // => "notes/Foo.md" or "a/notes/Foo.md"
+//	[[photo.png]]   // => "attachments/photo.png"
+func (v *Vault) Lookup(target string) (p string, ok bool) {
+	target = strings.Trim(target, "/")
+	if target == "" {
+		return "", false
+	}
+
+	k := key(target)
+	if p, ok := v.byPath[k]; ok {
+		return p, true
+	}
+
+	for _, p := range v.byName[key(path.Base(target))] {
+		candidate := key(p)
+		if IsPage(p) && !strings.HasSuffix(k, key(path.Ext(p))) {
+			candidate = key(trimExt(p))
+		}
+		if strings.HasSuffix(candidate, "/"+k) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// Name returns the name of the page or attachment at the provided path.
+// This is the base name of the file, without the extension for pages.
+//
+//	Name("notes/Foo.md")     // => "Foo"
+//	Name("images/photo.png") // => "photo.png"
+func Name(p string) string {
+	p = path.Base(p)
+	if IsPage(p) {
+		p = trimExt(p)
+	}
+	return p
+}
+
+// IsPage reports whether the file at the provided path is a page.
+func IsPage(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".md", ".markdown":
+		return true
+	default:
+		return false
+	}
+}
+
+func trimExt(p string) string {
+	return strings.TrimSuffix(p, path.Ext(p))
+}
+
+func key(s string) string {
+	return wikilink.DefaultNormalizer.Key([]byte(s))
+}
+
+// preferred reports whether path a should be preferred over path b
+// when both match a target: shallower paths first, then alphabetically.
+func preferred(a, b string) bool {
+	da, db := strings.Count(a, "/"), strings.Count(b, "/")
+	if da != db {
+		return da < db
+	}
+	return a < b
+}
@@ -0,0 +1,142 @@
+package vault
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+func testVault(t testing.TB) *Vault {
+	v, err := Load(fstest.MapFS{
+		"Home.md":                  {},
+		"notes/Foo Bar.md":         {},
+		"notes/Baz.markdown":       {},
+		"archive/notes/Foo Bar.md": {},
+		"archive/Old.md":           {},
+		"images/photo.png":         {},
+		"docs/manual.pdf":          {},
+		".obsidian/app.json":       {},
+		".hidden.md":               {},
+	})
+	require.NoError(t, err)
+	return v
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	v := testVault(t)
+	assert.Equal(t, []string{
+		"Home.md",
+		"archive/Old.md",
+		"archive/notes/Foo Bar.md",
+		"notes/Baz.markdown",
+		"notes/Foo Bar.md",
+	}, v.Pages())
+	assert.Equal(t, []string{
+		"docs/manual.pdf",
+		"images/photo.png",
+	}, v.Attachments())
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	v := testVault(t)
+	tests := []struct {
+		give string
+		want string // empty if not found
+	}{
+		{give: "Home", want: "Home.md"},
+		{give: "home", want: "Home.md"},
+		{give: "Home.md", want: "Home.md"},
+		{give: "/Home", want: "Home.md"},
+		{give: "Foo Bar", want: "notes/Foo Bar.md"},
+		{give: "foo_bar", want: "notes/Foo Bar.md"},
+		{give: "notes/Foo Bar", want: "notes/Foo Bar.md"},
+		{give: "archive/notes/Foo Bar", want: "archive/notes/Foo Bar.md"},
+		{give: "archive/notes/Foo Bar.md", want: "archive/notes/Foo Bar.md"},
+		{give: "Old", want: "archive/Old.md"},
+		{give: "Baz", want: "notes/Baz.markdown"},
+		{give: "Baz.markdown", want: "notes/Baz.markdown"},
+		{give: "photo.png", want: "images/photo.png"},
+		{give: "images/photo.png", want: "images/photo.png"},
+		{give: "manual.pdf", want: "docs/manual.pdf"},
+		{give: "photo"},
+		{give: "otes/Foo Bar"},
+		{give: "Missing"},
+		{give: ".hidden"},
+		{give: "app.json"},
+		{give: ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := v.Lookup(tt.give)
+		assert.Equal(t, tt.want != "", ok, "Lookup(%q)", tt.give)
+		assert.Equal(t, tt.want, got, "Lookup(%q)", tt.give)
+	}
+}
+
+func TestName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Foo", Name("notes/Foo.md"))
+	assert.Equal(t, "Foo", Name("Foo.markdown"))
+	assert.Equal(t, "photo.png", Name("images/photo.png"))
+}
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	resolver := &Resolver{
+		Vault: testVault(t),
+		URL: func(from, to string) string {
+			return from + "->" + to
+		},
+	}
+
+	tests := []struct {
+		desc     string
+		target   string
+		fragment string
+		want     string
+	}{
+		{desc: "page", target: "Home", want: "notes/Baz.markdown->Home.md"},
+		{
+			desc:     "fragment",
+			target:   "Foo Bar",
+			fragment: "Some Heading!",
+			want:     "notes/Baz.markdown->notes/Foo Bar.md#some-heading",
+		},
+		{desc: "same page", fragment: "Heading", want: "#heading"},
+		{desc: "attachment", target: "photo.png", want: "notes/Baz.markdown->images/photo.png"},
+		{desc: "missing", target: "Missing"},
+		{desc: "empty"},
+	}
+
+	ctx := WithPage(context.Background(), "notes/Baz.markdown")
+	for _, tt := range tests {
+		n := &wikilink.Node{
+			Target:   []byte(tt.target),
+			Fragment: []byte(tt.fragment),
+		}
+
+		got, err := resolver.ResolveWikilinkContext(ctx, n)
+		require.NoError(t, err, tt.desc)
+		if tt.want == "" {
+			assert.Nil(t, got, tt.desc)
+		} else {
+			assert.Equal(t, tt.want, string(got), tt.desc)
+		}
+	}
+
+	t.Run("no page", func(t *testing.T) {
+		got, err := resolver.ResolveWikilink(&wikilink.Node{Target: []byte("Home")})
+		require.NoError(t, err)
+		assert.Equal(t, "->Home.md", string(got))
+	})
+}
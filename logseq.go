@@ -0,0 +1,241 @@
+package wikilink
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// _maxLogseqDepth is the deepest that wikilinks may be nested
+// inside other wikilinks in the LogseqDialect.
+// Wikilinks with deeper nesting are not parsed.
+//
+// This bounds the work done for every "[[" in a line
+// so that pathological inputs like "[[[[[[..." can't slow parsing down.
+const _maxLogseqDepth = 16
+
+// parseLogseq parses a wikilink in the LogseqDialect.
+//
+//	[[...]]
+//	![[...]]
+//	{{embed [[...]]}}
+//
+// Wikilinks may contain nested wikilinks in their targets and labels.
+func (p *Parser) parseLogseq(block text.Reader, pc parser.Context) ast.Node {
+	line, seg := block.PeekLine()
+
+	var (
+		embed   bool
+		openLen int
+	)
+	switch {
+	case bytes.HasPrefix(line, _open):
+		openLen = len(_open)
+	case bytes.HasPrefix(line, _embedOpen):
+		embed = true
+		openLen = len(_embedOpen)
+	case bytes.HasPrefix(line, _embedMacro):
+		return p.parseEmbedMacro(block, pc)
+	default:
+		return nil
+	}
+
+	stop, closes := matchBrackets(line, openLen, seg.Start)
+	if stop < 0 {
+		return nil // must close on the same line
+	}
+
+	n := newLogseqNode(
+		block.Source(),
+		text.NewSegment(seg.Start, seg.Start+stop+len(_close)),
+		text.NewSegment(seg.Start+openLen, seg.Start+stop),
+		closes,
+		getConversion(pc),
+	)
+	if n == nil {
+		return nil
+	}
+	n.Embed = embed
+	block.Advance(stop + len(_close))
+	return n
+}
+
+// parseEmbedMacro parses a page embed macro.
+//
+//	{{embed [[...]]}}
+func (p *Parser) parseEmbedMacro(block text.Reader, pc parser.Context) ast.Node {
+	line, seg := block.PeekLine()
+
+	// {{embed  [[
+	//        ^^ one or more spaces
+	i := len(_embedMacro)
+	rest := bytes.TrimLeft(line[i:], " \t")
+	if len(rest) == len(line[i:]) || !bytes.HasPrefix(rest, _open) {
+		return nil
+	}
+	i = len(line) - len(rest) + len(_open)
+
+	stop, closes := matchBrackets(line, i, seg.Start)
+	if stop < 0 {
+		return nil
+	}
+
+	// ]]  }}
+	//   ^^ zero or more spaces
+	end := stop + len(_close)
+	rest = bytes.TrimLeft(line[end:], " \t")
+	if !bytes.HasPrefix(rest, _macroClose) {
+		return nil
+	}
+	end = len(line) - len(rest) + len(_macroClose)
+
+	n := newLogseqNode(
+		block.Source(),
+		text.NewSegment(seg.Start, seg.Start+end),
+		text.NewSegment(seg.Start+i, seg.Start+stop),
+		closes,
+		getConversion(pc),
+	)
+	if n == nil {
+		return nil
+	}
+	n.Embed = true
+	block.Advance(end)
+	return n
+}
+
+// newLogseqNode builds a Node for the wikilink at linkSeg
+// with the contents between its brackets at seg.
+// Nested wikilinks in the label become child Nodes.
+//
+// closes maps the offsets of nested "[[" in the source
+// to the offsets of the "]]" that close them.
+// See matchBrackets.
+//
+// It returns nil if the target or label is empty.
+func newLogseqNode(src []byte, linkSeg, seg text.Segment, closes map[int]int, conv *conversion) *Node {
+	// Only "|" and "#" outside nested wikilinks are significant.
+	pipe, hash := -1, -1
+	eachTopLevel(src, seg, closes, func(i int) {
+		switch src[i] {
+		case '|':
+			if pipe < 0 {
+				pipe = i - seg.Start
+			}
+		case '#':
+			if pipe < 0 {
+				hash = i - seg.Start
+			}
+		}
+	})
+
+	target := seg.Value(src)
+	if pipe >= 0 {
+		target = target[:pipe]                    // [[ ... |
+		seg = seg.WithStart(seg.Start + pipe + 1) // | ... ]]
+	}
+	if len(target) == 0 || seg.Len() == 0 {
+		return nil // target and label must not be empty
+	}
+
+	var fragment []byte
+	if hash >= 0 {
+		fragment = target[hash+1:]
+		target = target[:hash]
+	}
+
+	n := &Node{
+		Target:   target,
+		Fragment: fragment,
+		Segment:  linkSeg,
+		conv:     conv,
+	}
+	appendLabel(n, src, seg, closes, conv)
+	return n
+}
+
+// appendLabel appends the label at seg to the provided Node
+// as text and nested wikilinks.
+func appendLabel(n *Node, src []byte, seg text.Segment, closes map[int]int, conv *conversion) {
+	textStart := seg.Start
+	for i := seg.Start; i < seg.Stop; {
+		stop, ok := closes[i]
+		if !ok || src[i] != '[' {
+			i++
+			continue
+		}
+
+		open := i + len(_open)
+		child := newLogseqNode(
+			src,
+			text.NewSegment(i, stop+len(_close)),
+			text.NewSegment(open, stop),
+			closes,
+			conv,
+		)
+		if child == nil {
+			i = open
+			continue // [[]] is text
+		}
+
+		if textStart < i {
+			n.AppendChild(n, ast.NewTextSegment(text.NewSegment(textStart, i)))
+		}
+		n.AppendChild(n, child)
+		i = stop + len(_close)
+		textStart = i
+	}
+
+	if textStart < seg.Stop {
+		n.AppendChild(n, ast.NewTextSegment(text.NewSegment(textStart, seg.Stop)))
+	}
+}
+
+// matchBrackets finds the "]]" that closes a wikilink
+// whose contents start at index start of line,
+// matching the brackets of nested wikilinks in a single pass.
+//
+// It returns the index of the closing "]]" in line,
+// or -1 if the wikilink is not closed
+// or nests wikilinks deeper than _maxLogseqDepth.
+// closes maps the offset of the "[[" of each nested wikilink
+// to the offset of the "]]" that closes it.
+// Offsets in closes are in the source, where line starts at offset base.
+func matchBrackets(line []byte, start, base int) (stop int, closes map[int]int) {
+	var opens []int // offsets of unclosed nested "[["
+	for i := start; i+1 < len(line); i++ {
+		switch {
+		case line[i] == '[' && line[i+1] == '[':
+			if len(opens) >= _maxLogseqDepth {
+				return -1, nil
+			}
+			opens = append(opens, base+i)
+			i++
+		case line[i] == ']' && line[i+1] == ']':
+			if len(opens) == 0 {
+				return i, closes
+			}
+			if closes == nil {
+				closes = make(map[int]int)
+			}
+			closes[opens[len(opens)-1]] = base + i
+			opens = opens[:len(opens)-1]
+			i++
+		}
+	}
+	return -1, nil
+}
+
+// eachTopLevel calls f with the offset of every byte in seg
+// that is not inside a nested wikilink.
+func eachTopLevel(src []byte, seg text.Segment, closes map[int]int, f func(int)) {
+	for i := seg.Start; i < seg.Stop; i++ {
+		if stop, ok := closes[i]; ok && src[i] == '[' {
+			i = stop + len(_close) - 1
+			continue
+		}
+		f(i)
+	}
+}
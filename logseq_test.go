@@ -0,0 +1,315 @@
+package wikilink
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+func TestParser_logseq(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give string
+
+		wantTarget   string
+		wantFragment string
+		wantEmbed    bool
+
+		// wantChildren describes the children of the Node:
+		// text as quoted strings and nested wikilinks
+		// as their target followed by their children.
+		wantChildren string
+
+		remainder string // unconsumed portion of tt.give
+	}{
+		{
+			desc:         "simple",
+			give:         "[[foo]] bar",
+			wantTarget:   "foo",
+			wantChildren: `"foo"`,
+			remainder:    " bar",
+		},
+		{
+			desc:         "nested",
+			give:         "[[outer [[inner]] page]] baz",
+			wantTarget:   "outer [[inner]] page",
+			wantChildren: `"outer " inner("inner") " page"`,
+			remainder:    " baz",
+		},
+		{
+			desc:         "deeply nested",
+			give:         "[[a [[b [[c]]]] d]]",
+			wantTarget:   "a [[b [[c]]]] d",
+			wantChildren: `"a " b [[c]]("b " c("c")) " d"`,
+		},
+		{
+			desc:         "nested at edges",
+			give:         "[[[[a]] and [[b]]]]",
+			wantTarget:   "[[a]] and [[b]]",
+			wantChildren: `a("a") " and " b("b")`,
+		},
+		{
+			desc:         "nested label",
+			give:         "[[foo|see [[bar|baz]]]]",
+			wantTarget:   "foo",
+			wantChildren: `"see " bar("baz")`,
+		},
+		{
+			desc:         "pipe and hash in nested link",
+			give:         "[[a [[b#c|d]] e#f]]",
+			wantTarget:   "a [[b#c|d]] e",
+			wantFragment: "f",
+			wantChildren: `"a " b#c("d") " e#f"`,
+		},
+		{
+			desc:         "empty nested link",
+			give:         "[[a [[]] b]]",
+			wantTarget:   "a [[]] b",
+			wantChildren: `"a [[]] b"`,
+		},
+		{
+			desc:         "embed",
+			give:         "![[outer [[inner]]]]",
+			wantTarget:   "outer [[inner]]",
+			wantEmbed:    true,
+			wantChildren: `"outer " inner("inner")`,
+		},
+		{
+			desc:         "embed macro",
+			give:         "{{embed [[Page]]}} after",
+			wantTarget:   "Page",
+			wantEmbed:    true,
+			wantChildren: `"Page"`,
+			remainder:    " after",
+		},
+		{
+			desc:         "embed macro with spaces",
+			give:         "{{embed   [[Page#Section|label]]  }}",
+			wantTarget:   "Page",
+			wantFragment: "Section",
+			wantEmbed:    true,
+			wantChildren: `"label"`,
+		},
+		{
+			desc:         "embed macro nested",
+			give:         "{{embed [[a [[b]]]]}}",
+			wantTarget:   "a [[b]]",
+			wantEmbed:    true,
+			wantChildren: `"a " b("b")`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			r := text.NewReader([]byte(tt.give))
+
+			p := Parser{Dialect: LogseqDialect}
+			got := p.Parse(nil /* parent */, r, parser.NewContext())
+			require.NotNil(t, got, "expected Node, got nil")
+
+			n, ok := got.(*Node)
+			require.True(t, ok, "expected Node, got %T", got)
+			assert.Equal(t, tt.wantTarget, string(n.Target), "target mismatch")
+			assert.Equal(t, tt.wantFragment, string(n.Fragment), "fragment mismatch")
+			assert.Equal(t, tt.wantEmbed, n.Embed, "embed mismatch")
+			assert.Equal(t, tt.give[:len(tt.give)-len(tt.remainder)],
+				string(n.Segment.Value(r.Source())), "segment mismatch")
+			assert.Equal(t, tt.wantChildren, describeChildren(r.Source(), n), "children mismatch")
+
+			_, pos := r.Position()
+			assert.Equal(t, tt.remainder, string(r.Value(pos)),
+				"remaining text does not match")
+		})
+	}
+}
+
+func TestParser_logseqRejects(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"[[]]",
+		"[[foo",
+		"[[a [[b]]",
+		"[[a [[b]] c]",
+		"[foo]",
+		"{{embed}}",
+		"{{embed [[Page]]",
+		"{{embed ((block-id))}}",
+		"{{embed[[Page]]}}",
+		"{{video https://example.com}}",
+		"{foo}",
+	}
+
+	for _, give := range tests {
+		p := Parser{Dialect: LogseqDialect}
+		r := text.NewReader([]byte(give))
+		assert.Nil(t, p.Parse(nil /* parent */, r, parser.NewContext()), "Parse(%q)", give)
+	}
+}
+
+func TestParser_logseqDepth(t *testing.T) {
+	t.Parallel()
+
+	nested := func(depth int) string {
+		return strings.Repeat("[[a", depth) + strings.Repeat("]]", depth)
+	}
+
+	p := Parser{Dialect: LogseqDialect}
+
+	// The outermost wikilink is not nested.
+	give := nested(_maxLogseqDepth + 1)
+	got := p.Parse(nil /* parent */, text.NewReader([]byte(give)), parser.NewContext())
+	require.NotNil(t, got, "Parse(%q)", give)
+	var depth int
+	for n := ast.Node(got); n != nil; n = n.LastChild() {
+		if _, ok := n.(*Node); ok {
+			depth++
+		}
+	}
+	assert.Equal(t, _maxLogseqDepth+1, depth)
+
+	give = nested(_maxLogseqDepth + 2)
+	got = p.Parse(nil /* parent */, text.NewReader([]byte(give)), parser.NewContext())
+	assert.Nil(t, got, "Parse(%q)", give)
+}
+
+func TestParser_Trigger(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []byte("!["), (&Parser{}).Trigger())
+	assert.Equal(t, []byte("![{"), (&Parser{Dialect: LogseqDialect}).Trigger())
+}
+
+func TestLogseqDialect_render(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give string
+		want string
+	}{
+		{
+			desc: "nested",
+			give: "[[outer [[inner]] page]]",
+			want: `<p><a href="outer%20%5B%5Binner%5D%5D%20page.html">outer [[inner]] page</a></p>`,
+		},
+		{
+			desc: "nested with label",
+			give: "[[foo|see [[bar]]]]",
+			want: `<p><a href="foo.html">see [[bar]]</a></p>`,
+		},
+		{
+			desc: "embed macro",
+			give: "{{embed [[Page]]}}",
+			want: `<p><a href="Page.html">Page</a></p>`,
+		},
+		{
+			desc: "embed macro image",
+			give: "See {{embed [[cat.png|a cat]]}}",
+			want: `<p>See <img src="cat.png" alt="a cat"></p>`,
+		},
+		{
+			desc: "code span",
+			give: "`{{embed [[Page]]}}`",
+			want: `<p><code>{{embed [[Page]]}}</code></p>`,
+		},
+		{
+			desc: "other macro",
+			give: "{{query [[foo]]}}",
+			want: `<p>{{query <a href="foo.html">foo</a>}}</p>`,
+		},
+	}
+
+	md := goldmark.New(goldmark.WithExtensions(&Extender{Dialect: LogseqDialect}))
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			require.NoError(t, md.Convert([]byte(tt.give), &buf))
+			assert.Equal(t, tt.want, strings.TrimSpace(buf.String()))
+		})
+	}
+}
+
+func TestLogseqDialect_unresolvedOuter(t *testing.T) {
+	t.Parallel()
+
+	resolver := resolverFunc(func(n *Node) ([]byte, error) {
+		if string(n.Target) == "inner" {
+			return []byte("inner.html"), nil
+		}
+		return nil, nil
+	})
+
+	md := goldmark.New(goldmark.WithExtensions(&Extender{
+		Dialect:  LogseqDialect,
+		Resolver: resolver,
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte("[[outer [[inner]]]]"), &buf))
+	assert.Equal(t, `<p>outer <a href="inner.html">inner</a></p>`, strings.TrimSpace(buf.String()),
+		"nested links are rendered if the outer link isn't")
+}
+
+func TestLogseqDialect_markdownRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"[[outer [[inner]] page]]",
+		"[[foo|see [[bar]]]]",
+		"![[a [[b]]]]",
+		"{{embed [[Page]]}}",
+		"{{embed [[Page#Section|label]]}}",
+	}
+
+	for _, give := range tests {
+		give := give
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			src := []byte(give)
+			p := Parser{Dialect: LogseqDialect}
+			n := p.Parse(nil /* parent */, text.NewReader(src), parser.NewContext())
+			require.NotNil(t, n, "parse failed")
+
+			assert.Equal(t, give, renderMarkdown(t, src, n))
+		})
+	}
+}
+
+// describeChildren describes the children of a node for tests.
+// See TestParser_logseq.
+func describeChildren(src []byte, n ast.Node) string {
+	var parts []string
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			parts = append(parts, fmt.Sprintf("%q", c.Segment.Value(src)))
+		case *Node:
+			target := string(c.Target)
+			if len(c.Fragment) > 0 {
+				target += "#" + string(c.Fragment)
+			}
+			parts = append(parts, fmt.Sprintf("%v(%v)", target, describeChildren(src, c)))
+		default:
+			parts = append(parts, fmt.Sprintf("%T", c))
+		}
+	}
+	return strings.Join(parts, " ")
+}
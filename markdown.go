@@ -0,0 +1,133 @@
+package wikilink
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// MarkdownRenderer renders wikilinks back into wikilink syntax.
+// Use it with goldmark Renderers that produce Markdown,
+// like the one provided by goldmark-markdown.
+//
+//	[[Foo#Bar|baz]]  => [[Foo#Bar|baz]]
+//	![[foo.png]]     => ![[foo.png]]
+//
+// Labels are included only if they were present in the source,
+// or for nodes that were not parsed from a source,
+// if they are different from the target.
+// WikiWords and mentions linked by AutoLinkTransformer
+// are written back as plain text,
+// and figures made by FigureTransformer as the image embeds they came from.
+//
+// Install it on a goldmark Renderer by using the WithNodeRenderers option.
+//
+//	markdownRenderer := util.Prioritized(&wikilink.MarkdownRenderer{}, 199)
+//	goldmarkRenderer.AddOptions(renderer.WithNodeRenderers(markdownRenderer))
+type MarkdownRenderer struct{}
+
+var _ renderer.NodeRenderer = (*MarkdownRenderer)(nil)
+
+// RegisterFuncs registers wikilink rendering functions with the provided
+// goldmark registerer.
+func (r *MarkdownRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(Kind, r.Render)
+	reg.Register(FigureKind, r.renderFigure)
+	reg.Register(WikiWordEscapeKind, r.renderWikiWordEscape)
+}
+
+// Render renders the provided Node in wikilink syntax.
+// It must be a Wikilink [Node].
+func (r *MarkdownRenderer) Render(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n, ok := node.(*Node)
+	if !ok {
+		return ast.WalkStop, fmt.Errorf("unexpected node %T, expected *wikilink.Node", node)
+	}
+
+	// WikiWords and auto-linked mentions are written as they appeared.
+	if n.bare {
+		_, _ = w.Write(nodeText(src, n))
+		return ast.WalkSkipChildren, nil
+	}
+
+	// Keep Logseq's embed macro as it was written.
+	macro := n.Embed && bytes.HasPrefix(n.Segment.Value(src), _embedMacro)
+	switch {
+	case macro:
+		_, _ = w.Write(_embedMacro)
+		_ = w.WriteByte(' ')
+		_, _ = w.Write(_open)
+	case n.Embed:
+		_, _ = w.Write(_embedOpen)
+	default:
+		_, _ = w.Write(_open)
+	}
+	if label := nodeText(src, n); hasExplicitLabel(src, n, label) {
+		_, _ = w.Write(n.Target)
+		if len(n.Fragment) > 0 {
+			_, _ = w.Write(_hash)
+			_, _ = w.Write(n.Fragment)
+		}
+		_, _ = w.Write(_pipe)
+		_, _ = w.Write(label)
+	} else {
+		// Implicit labels are the target as written in the source.
+		// Writing that back preserves details lost while parsing
+		// like the "#" in [[foo#]].
+		_, _ = w.Write(label)
+	}
+	_, _ = w.Write(_close)
+	if macro {
+		_, _ = w.Write(_macroClose)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// hasExplicitLabel reports whether the wikilink was written
+// with a label in the form [[target|label]].
+func hasExplicitLabel(src []byte, n *Node, label []byte) bool {
+	// For nodes parsed from the source,
+	// the label is preceded by a "|" only if it was explicit.
+	if t, ok := n.FirstChild().(*ast.Text); ok && n.ChildCount() == 1 {
+		if start := t.Segment.Start; start > 0 && start <= len(src) {
+			return src[start-1] == '|'
+		}
+	}
+
+	// Otherwise, the label is explicit
+	// if it's different from the implied label.
+	implied := n.Target
+	if len(n.Fragment) > 0 {
+		implied = make([]byte, 0, len(n.Target)+len(_hash)+len(n.Fragment))
+		implied = append(implied, n.Target...)
+		implied = append(implied, _hash...)
+		implied = append(implied, n.Fragment...)
+	}
+	return !bytes.Equal(label, implied)
+}
+
+// renderWikiWordEscape writes the "!" that escapes a WikiWord.
+// The WikiWord itself is rendered as the node's child.
+func (r *MarkdownRenderer) renderWikiWordEscape(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_ = w.WriteByte('!')
+	}
+	return ast.WalkContinue, nil
+}
+
+// renderFigure renders a Figure as the image embed it was made from
+// on a line of its own.
+// The image itself is rendered as the node's child.
+func (r *MarkdownRenderer) renderFigure(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_ = w.WriteByte('\n')
+	}
+	return ast.WalkContinue, nil
+}
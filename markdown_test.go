@@ -0,0 +1,162 @@
+package wikilink
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+func TestMarkdownRenderer_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"[[foo]]",
+		"[[foo bar]]",
+		"[[foo|bar]]",
+		"[[foo|foo]]",
+		"[[foo#bar]]",
+		"[[foo#bar|foo#bar]]",
+		"[[foo#bar|baz]]",
+		"[[#foo]]",
+		"[[foo#]]",
+		"[[#]]",
+		"[[#foo|bar]]",
+		"![[foo.png]]",
+		"![[foo.png|alt text]]",
+		"[[foo *bar*|*baz*]]",
+	}
+
+	for _, give := range tests {
+		give := give
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			src := []byte(give)
+			var p Parser
+			n := p.Parse(nil /* parent */, text.NewReader(src), parser.NewContext())
+			require.NotNil(t, n, "parse failed")
+
+			assert.Equal(t, give, renderMarkdown(t, src, n))
+		})
+	}
+}
+
+func TestMarkdownRenderer_programmatic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		give  *Node
+		label string
+		want  string
+	}{
+		{
+			desc:  "same label",
+			give:  &Node{Target: []byte("foo")},
+			label: "foo",
+			want:  "[[foo]]",
+		},
+		{
+			desc:  "different label",
+			give:  &Node{Target: []byte("foo")},
+			label: "bar",
+			want:  "[[foo|bar]]",
+		},
+		{
+			desc:  "fragment",
+			give:  &Node{Target: []byte("foo"), Fragment: []byte("bar")},
+			label: "foo#bar",
+			want:  "[[foo#bar]]",
+		},
+		{
+			desc:  "embed",
+			give:  &Node{Target: []byte("foo.png"), Embed: true},
+			label: "bar",
+			want:  "![[foo.png|bar]]",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			tt.give.AppendChild(tt.give, ast.NewString([]byte(tt.label)))
+			assert.Equal(t, tt.want, renderMarkdown(t, nil, tt.give))
+		})
+	}
+}
+
+func TestMarkdownRenderer_figures(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"![[foo.png]]\n",
+		"![[foo.png|A foo]]\n",
+	}
+
+	for _, give := range tests {
+		give := give
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, give, renderFigures(t, &MarkdownRenderer{}, give))
+		})
+	}
+}
+
+func TestMarkdownRenderer_IncorrectNode(t *testing.T) {
+	t.Parallel()
+
+	var r MarkdownRenderer
+	_, err := r.Render(bufio.NewWriter(io.Discard), nil /* src */, ast.NewText(), true /* enter */)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected node")
+}
+
+func renderMarkdown(t testing.TB, src []byte, n ast.Node) string {
+	var (
+		r    MarkdownRenderer
+		buff bytes.Buffer
+	)
+	w := bufio.NewWriter(&buff)
+
+	status, err := r.Render(w, src, n, true /* entering */)
+	require.NoError(t, err)
+	assert.Equal(t, ast.WalkSkipChildren, status)
+
+	_, err = r.Render(w, src, n, false /* entering */)
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	return buff.String()
+}
+
+// renderFigures parses the provided source with FigureTransformer
+// and renders it with only the provided NodeRenderer.
+// Nodes that it doesn't render, like paragraphs, are not written.
+func renderFigures(t testing.TB, nr renderer.NodeRenderer, src string) string {
+	p := parser.NewParser(
+		parser.WithBlockParsers(parser.DefaultBlockParsers()...),
+		parser.WithInlineParsers(append(
+			parser.DefaultInlineParsers(),
+			util.Prioritized(&Parser{}, 199),
+		)...),
+		parser.WithASTTransformers(util.Prioritized(&FigureTransformer{}, 199)),
+	)
+	doc := p.Parse(text.NewReader([]byte(src)))
+	require.IsType(t, &Figure{}, doc.FirstChild(), "expected a figure")
+
+	var buf bytes.Buffer
+	r := renderer.NewRenderer(renderer.WithNodeRenderers(util.Prioritized(nr, 199)))
+	require.NoError(t, r.Render(&buf, []byte(src), doc))
+	return buf.String()
+}
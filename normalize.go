@@ -0,0 +1,208 @@
+package wikilink
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultNormalizer is a Normalizer that makes target matching
+// case-insensitive and tolerant of differences in spacing,
+// similar to MediaWiki.
+//
+// For example, all of the following produce the same key.
+//
+//	[[Foo Bar]]
+//	[[foo bar]]
+//	[[Foo_Bar]]
+//	[[ foo   bar ]]
+var DefaultNormalizer = &Normalizer{
+	FoldCase:          true,
+	CollapseSpace:     true,
+	UnderscoreIsSpace: true,
+}
+
+// Normalizer canonicalizes wikilink targets so that different spellings
+// of the same page name produce the same key.
+// Use it to build indexes of pages that wikilinks can be looked up in.
+//
+// The zero value of Normalizer applies only Unicode normalization.
+type Normalizer struct {
+	// Form is the Unicode normalization form applied to targets.
+	// Use this to match precomposed and decomposed forms
+	// of the same text. For example, "Café" as NFC and as NFD.
+	//
+	// Defaults to NFC.
+	Form norm.Form
+
+	// FoldCase enables Unicode case folding
+	// so that [[Foo]] and [[foo]] produce the same key.
+	FoldCase bool
+
+	// CollapseSpace trims leading and trailing whitespace
+	// and replaces runs of whitespace inside a target with a single space.
+	CollapseSpace bool
+
+	// UnderscoreIsSpace treats underscores as spaces
+	// so that [[Foo_bar]] and [[Foo bar]] produce the same key.
+	UnderscoreIsSpace bool
+}
+
+// Normalize returns the normalized form of the provided target.
+// The input is not modified.
+func (nz *Normalizer) Normalize(target []byte) []byte {
+	out := target
+	if nz.UnderscoreIsSpace && bytes.IndexByte(out, '_') >= 0 {
+		out = bytes.ReplaceAll(out, []byte{'_'}, []byte{' '})
+	}
+	if nz.CollapseSpace {
+		out = collapseSpace(out)
+	}
+
+	out = nz.Form.Bytes(out)
+	if nz.FoldCase {
+		// Case folding may produce denormalized text
+		// so normalize again afterwards.
+		out = nz.Form.Bytes(cases.Fold().Bytes(out))
+	}
+	return out
+}
+
+// Key returns the canonical key for the provided target.
+// Two targets refer to the same page if they have the same key.
+func (nz *Normalizer) Key(target []byte) string {
+	return string(nz.Normalize(target))
+}
+
+// CanonicalKey returns the canonical key for the provided target
+// using DefaultNormalizer.
+func CanonicalKey(target []byte) string {
+	return DefaultNormalizer.Key(target)
+}
+
+// collapseSpace trims surrounding whitespace from s and replaces runs of
+// whitespace inside it with a single space.
+func collapseSpace(s []byte) []byte {
+	out := make([]byte, 0, len(s))
+	var pendingSpace bool
+	for len(s) > 0 {
+		r, size := utf8.DecodeRune(s)
+		if unicode.IsSpace(r) {
+			pendingSpace = len(out) > 0
+		} else {
+			if pendingSpace {
+				out = append(out, ' ')
+				pendingSpace = false
+			}
+			out = append(out, s[:size]...)
+		}
+		s = s[size:]
+	}
+	return out
+}
+
+// NormalizingResolver is a Resolver that matches the targets of wikilinks
+// against a list of known pages by their normalized keys,
+// and passes wikilinks on to another Resolver
+// with the names of the pages they matched.
+//
+// For example, with the DefaultNormalizer
+// and a page named "Foo Bar",
+// [[foo_bar]] and [[FOO  BAR]] are both resolved as [[Foo Bar]].
+//
+// Use this to opt into case-insensitive or whitespace-insensitive
+// matching with an existing Resolver.
+//
+// A NormalizingResolver is safe for concurrent use
+// once its fields are set.
+type NormalizingResolver struct {
+	// Resolver resolves wikilinks
+	// with targets replaced by the names of the pages they matched.
+	// Targets that don't match a page are passed on as-is.
+	//
+	// Defaults to DefaultResolver if unspecified.
+	Resolver Resolver
+
+	// Pages lists the names of known pages.
+	// Where more than one page has the same key,
+	// earlier pages take precedence over later ones.
+	//
+	// Pages must not be changed after the first call to ResolveWikilink.
+	Pages []string
+
+	// Normalizer produces the keys that targets are matched by.
+	//
+	// Defaults to DefaultNormalizer if unspecified.
+	// Normalizer must not be changed after the first call to ResolveWikilink.
+	Normalizer *Normalizer
+
+	once  sync.Once
+	pages map[string]string // key => page name
+}
+
+var _ ContextResolver = (*NormalizingResolver)(nil)
+
+// ResolveWikilink resolves the provided Node
+// with the underlying Resolver,
+// using the name of the page that its target matched.
+// The provided Node is not modified.
+func (r *NormalizingResolver) ResolveWikilink(n *Node) ([]byte, error) {
+	return r.ResolveWikilinkContext(context.Background(), n)
+}
+
+// ResolveWikilinkContext is a variant of ResolveWikilink
+// that passes the context to the underlying Resolver
+// if it implements ContextResolver.
+func (r *NormalizingResolver) ResolveWikilinkContext(ctx context.Context, n *Node) ([]byte, error) {
+	r.once.Do(r.build)
+
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = DefaultResolver
+	}
+
+	target := n.Target
+	if len(target) > 0 {
+		if name, ok := r.pages[r.normalizer().Key(target)]; ok {
+			target = []byte(name)
+		}
+	}
+
+	// Resolve a fresh Node rather than a copy of n
+	// so that the Resolver doesn't see n's place in the AST
+	// or the Renderer's state for it.
+	matched := &Node{
+		Target:   target,
+		Fragment: n.Fragment,
+		Embed:    n.Embed,
+		Segment:  n.Segment,
+		conv:     n.conv,
+	}
+	if cr, ok := resolver.(ContextResolver); ok {
+		return cr.ResolveWikilinkContext(ctx, matched)
+	}
+	return resolver.ResolveWikilink(matched)
+}
+
+func (r *NormalizingResolver) build() {
+	nz := r.normalizer()
+	r.pages = make(map[string]string, len(r.Pages))
+	for _, name := range r.Pages {
+		key := nz.Key([]byte(name))
+		if _, ok := r.pages[key]; !ok {
+			r.pages[key] = name
+		}
+	}
+}
+
+func (r *NormalizingResolver) normalizer() *Normalizer {
+	if r.Normalizer != nil {
+		return r.Normalizer
+	}
+	return DefaultNormalizer
+}
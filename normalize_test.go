@@ -0,0 +1,189 @@
+package wikilink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark/ast"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestNormalizer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc       string
+		normalizer *Normalizer
+		give       string
+		want       string
+	}{
+		{
+			desc:       "zero/unchanged",
+			normalizer: &Normalizer{},
+			give:       "Foo_Bar  baz",
+			want:       "Foo_Bar  baz",
+		},
+		{
+			desc:       "zero/NFC",
+			normalizer: &Normalizer{},
+			give:       "Café",
+			want:       "Café",
+		},
+		{
+			desc:       "NFKC",
+			normalizer: &Normalizer{Form: norm.NFKC},
+			give:       "ﬁle", // "fi" ligature
+			want:       "file",
+		},
+		{
+			desc:       "fold case",
+			normalizer: &Normalizer{FoldCase: true},
+			give:       "Foo BAR",
+			want:       "foo bar",
+		},
+		{
+			desc:       "fold case/unicode",
+			normalizer: &Normalizer{FoldCase: true},
+			give:       "STRASSE Straße",
+			want:       "strasse strasse",
+		},
+		{
+			desc:       "collapse space",
+			normalizer: &Normalizer{CollapseSpace: true},
+			give:       " \tfoo   bar  baz ",
+			want:       "foo bar baz",
+		},
+		{
+			desc:       "underscore",
+			normalizer: &Normalizer{UnderscoreIsSpace: true},
+			give:       "foo_bar__baz",
+			want:       "foo bar  baz",
+		},
+		{
+			desc:       "default",
+			normalizer: DefaultNormalizer,
+			give:       "_Café__Del_MAR ",
+			want:       "café del mar",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			give := []byte(tt.give)
+			got := tt.normalizer.Normalize(give)
+			assert.Equal(t, tt.want, string(got), "normalized mismatch")
+			assert.Equal(t, tt.want, tt.normalizer.Key(give), "key mismatch")
+			assert.Equal(t, tt.give, string(give), "input must not be modified")
+		})
+	}
+}
+
+func TestCanonicalKey(t *testing.T) {
+	t.Parallel()
+
+	want := CanonicalKey([]byte("Café Society"))
+	for _, give := range []string{
+		"cafe\u0301 society",
+		"Café_Society",
+		"CAFÉ   SOCIETY",
+	} {
+		assert.Equal(t, want, CanonicalKey([]byte(give)), "key for %q", give)
+	}
+}
+
+func TestNormalizingResolver(t *testing.T) {
+	t.Parallel()
+
+	pages := []string{"Foo Bar", "Café", "foo_bar"}
+
+	tests := []struct {
+		desc string
+		give *Node
+		want string
+	}{
+		{
+			desc: "exact",
+			give: &Node{Target: []byte("Foo Bar")},
+			want: "Foo Bar.html",
+		},
+		{
+			desc: "normalized",
+			give: &Node{Target: []byte(" foo_BAR "), Fragment: []byte("Baz")},
+			want: "Foo Bar.html#Baz",
+		},
+		{
+			desc: "unicode",
+			give: &Node{Target: []byte("CAFE\u0301")},
+			want: "Café.html",
+		},
+		{
+			desc: "unknown page",
+			give: &Node{Target: []byte("Qux_Quux")},
+			want: "Qux_Quux.html",
+		},
+		{
+			desc: "fragment only",
+			give: &Node{Fragment: []byte("Baz")},
+			want: "#Baz",
+		},
+	}
+
+	r := NormalizingResolver{Pages: pages}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			target := string(tt.give.Target)
+			got, err := r.ResolveWikilink(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, target, string(tt.give.Target), "node must not be modified")
+		})
+	}
+
+	t.Run("custom", func(t *testing.T) {
+		t.Parallel()
+
+		r := NormalizingResolver{
+			Pages:      []string{"Foo_Bar"},
+			Normalizer: &Normalizer{FoldCase: true},
+			Resolver: resolverFunc(func(n *Node) ([]byte, error) {
+				return append([]byte("/wiki/"), n.Target...), nil
+			}),
+		}
+		got, err := r.ResolveWikilink(&Node{Target: []byte("foo_bar")})
+		require.NoError(t, err)
+		assert.Equal(t, "/wiki/Foo_Bar", string(got))
+	})
+
+	t.Run("fresh node", func(t *testing.T) {
+		t.Parallel()
+
+		var got *Node
+		r := NormalizingResolver{
+			Resolver: resolverFunc(func(n *Node) ([]byte, error) {
+				got = n
+				return nil, nil
+			}),
+		}
+
+		para := ast.NewParagraph()
+		n := &Node{Target: []byte("Foo"), Embed: true}
+		para.AppendChild(para, n)
+		n.resolution = &resolution{dest: []byte("foo")}
+
+		_, err := r.ResolveWikilink(n)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.NotSame(t, n, got)
+		assert.Equal(t, "Foo", string(got.Target))
+		assert.True(t, got.Embed)
+		assert.Nil(t, got.Parent())
+		assert.Nil(t, got.resolution)
+	})
+}
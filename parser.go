@@ -8,6 +8,31 @@ import (
 	"github.com/yuin/goldmark/text"
 )
 
+// Dialect selects extensions to the wikilink syntax
+// used by specific note-taking tools.
+type Dialect int
+
+const (
+	// DefaultDialect supports the wikilink syntax documented on Parser.Parse.
+	// Wikilinks end at the first "]]" and cannot be nested.
+	DefaultDialect Dialect = iota
+
+	// LogseqDialect additionally supports the syntax of Logseq and Roam.
+	//
+	// Wikilinks may be nested inside other wikilinks.
+	// The target of the outer wikilink includes the nested wikilinks,
+	// and each nested wikilink becomes a child Node of the outer one.
+	// Wikilinks nested more than 16 levels deep are not parsed.
+	//
+	//	[[outer [[inner]] page]]  // target: "outer [[inner]] page"
+	//
+	// Pages may be embedded with the embed macro.
+	// This produces an embedded Node the same as ![[Page]].
+	//
+	//	{{embed [[Page]]}}
+	LogseqDialect
+)
+
 // Parser parses wikilinks.
 //
 // Install it on your goldmark Markdown object with Extender, or install it
@@ -18,7 +43,14 @@ import (
 //
 // Note that the priority for the wikilink parser must 199 or lower to take
 // precedence over the plain Markdown link parser which has a priority of 200.
-type Parser struct{}
+//
+// A Parser is safe for concurrent use by multiple goroutines.
+type Parser struct {
+	// Dialect specifies extensions to the wikilink syntax to support.
+	//
+	// Defaults to DefaultDialect.
+	Dialect Dialect
+}
 
 var _ parser.InlineParser = (*Parser)(nil)
 
@@ -28,11 +60,26 @@ var (
 	_pipe      = []byte{'|'}
 	_hash      = []byte{'#'}
 	_close     = []byte("]]")
+	_trigger   = []byte{'!', '['}
+
+	_embedMacro    = []byte("{{embed")
+	_macroClose    = []byte("}}")
+	_logseqTrigger = []byte{'!', '[', '{'}
 )
 
 // Trigger returns characters that trigger this parser.
 func (p *Parser) Trigger() []byte {
-	return []byte{'!', '['}
+	if p.Dialect == LogseqDialect {
+		return _logseqTrigger
+	}
+	return _trigger
+}
+
+// labeledNode holds a Node and the text node for its label
+// so that both can be allocated together.
+type labeledNode struct {
+	node  Node
+	label ast.Text
 }
 
 // Parse parses a wikilink in one of the following forms:
@@ -50,42 +97,72 @@ func (p *Parser) Trigger() []byte {
 // The target may optionally contain a fragment identifier:
 //
 //	[[target#fragment]]
-func (p *Parser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
-	line, seg := block.PeekLine()
-	stop := bytes.Index(line, _close)
-	if stop < 0 {
-		return nil // must close on the same line
+//
+// See Dialect for other forms supported by specific dialects.
+func (p *Parser) Parse(_ ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	if p.Dialect == LogseqDialect {
+		return p.parseLogseq(block, pc)
 	}
 
-	var embed bool
+	line, seg := block.PeekLine()
 
+	// Check the opening brackets before searching for the closing ones.
+	// The triggers are common in regular Markdown links and text,
+	// and most of the time, they're not followed by wikilinks.
+	var (
+		embed   bool
+		openLen int
+	)
 	switch {
 	case bytes.HasPrefix(line, _open):
-		seg = text.NewSegment(seg.Start+len(_open), seg.Start+stop)
+		openLen = len(_open)
 	case bytes.HasPrefix(line, _embedOpen):
 		embed = true
-		seg = text.NewSegment(seg.Start+len(_embedOpen), seg.Start+stop)
+		openLen = len(_embedOpen)
 	default:
 		return nil
 	}
 
-	n := &Node{Target: block.Value(seg), Embed: embed}
-	if idx := bytes.Index(n.Target, _pipe); idx >= 0 {
-		n.Target = n.Target[:idx]                // [[ ... |
+	stop := bytes.Index(line[openLen:], _close)
+	if stop < 0 {
+		return nil // must close on the same line
+	}
+	stop += openLen
+
+	linkSeg := text.NewSegment(seg.Start, seg.Start+stop+len(_close))
+	seg = text.NewSegment(seg.Start+openLen, seg.Start+stop)
+
+	target := block.Value(seg)
+	if idx := bytes.Index(target, _pipe); idx >= 0 {
+		target = target[:idx]                    // [[ ... |
 		seg = seg.WithStart(seg.Start + idx + 1) // | ... ]]
 	}
 
-	if len(n.Target) == 0 || seg.Len() == 0 {
+	if len(target) == 0 || seg.Len() == 0 {
 		return nil // target and label must not be empty
 	}
 
 	// Target may be Foo#Bar, so break them apart.
-	if idx := bytes.LastIndex(n.Target, _hash); idx >= 0 {
-		n.Fragment = n.Target[idx+1:] // Foo#Bar => Bar
-		n.Target = n.Target[:idx]     // Foo#Bar => Foo
+	var fragment []byte
+	if idx := bytes.LastIndex(target, _hash); idx >= 0 {
+		fragment = target[idx+1:] // Foo#Bar => Bar
+		target = target[:idx]     // Foo#Bar => Foo
+	}
+
+	// Allocate the node and its label together.
+	ln := &labeledNode{
+		node: Node{
+			Target:   target,
+			Fragment: fragment,
+			Embed:    embed,
+			Segment:  linkSeg,
+			conv:     getConversion(pc),
+		},
 	}
+	ln.label.Segment = seg
 
-	n.AppendChild(n, ast.NewTextSegment(seg))
-	block.Advance(stop + 2)
+	n := &ln.node
+	n.AppendChild(n, &ln.label)
+	block.Advance(stop + len(_close))
 	return n
 }
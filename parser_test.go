@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
@@ -121,6 +122,8 @@ func TestParser(t *testing.T) {
 				assert.Equal(t, tt.wantTarget, string(n.Target), "target mismatch")
 				assert.Equal(t, tt.wantFragment, string(n.Fragment), "fragment mismatch")
 				assert.Equal(t, tt.wantEmbed, n.Embed, "embed mismatch")
+				assert.Equal(t, tt.give[:len(tt.give)-len(tt.remainder)],
+					string(n.Segment.Value(r.Source())), "segment mismatch")
 			}
 
 			if assert.Equal(t, 1, got.ChildCount(), "children mismatch") {
@@ -136,3 +139,63 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_allocs(t *testing.T) {
+	tests := []struct {
+		desc string
+		give string
+		want float64
+	}{
+		{desc: "link", give: "[[foo#bar|baz]] qux", want: 1},
+		{desc: "embed", give: "![[foo.png]]", want: 1},
+		{desc: "markdown link", give: "[foo](bar) [[baz]]", want: 0},
+		{desc: "bang", give: "! [[foo]]", want: 0},
+		{desc: "unclosed", give: "[[foo bar", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var p Parser
+			r := text.NewReader([]byte(tt.give))
+			line, pos := r.Position()
+			pc := parser.NewContext()
+
+			n := p.Parse(nil /* parent */, r, pc)
+			assert.Equal(t, tt.want > 0, n != nil, "parse result")
+
+			got := testing.AllocsPerRun(100, func() {
+				r.SetPosition(line, pos)
+				_ = p.Parse(nil /* parent */, r, pc)
+			})
+			t.Logf("%v allocations per Parse", got)
+			assert.LessOrEqual(t, got, tt.want, "allocations")
+		})
+	}
+}
+
+func BenchmarkParser(b *testing.B) {
+	b.Run("link", func(b *testing.B) {
+		var p Parser
+		r := text.NewReader([]byte("[[foo bar#baz|qux]]"))
+		line, pos := r.Position()
+		pc := parser.NewContext()
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r.SetPosition(line, pos)
+			if p.Parse(nil /* parent */, r, pc) == nil {
+				b.Fatal("expected a wikilink")
+			}
+		}
+	})
+
+	md := goldmark.New(goldmark.WithExtensions(&Extender{}))
+	for _, corpus := range _benchmarkCorpora {
+		b.Run(corpus.name, func(b *testing.B) {
+			b.SetBytes(int64(len(corpus.src)))
+			reportAllocsPerLink(b, corpus.links, func() {
+				_ = md.Parser().Parse(text.NewReader(corpus.src))
+			})
+		})
+	}
+}
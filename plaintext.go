@@ -0,0 +1,101 @@
+package wikilink
+
+import (
+	"fmt"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// TextRenderer renders wikilinks as plain text.
+// Use it with goldmark Renderers that produce plain text,
+// for example, for search indexes or plain text emails.
+//
+// By default, only the label of the wikilink is rendered.
+//
+//	[[Foo|bar]]  => bar
+//
+// Figures made by FigureTransformer are rendered on their own lines
+// as the image followed by its caption.
+//
+//	![[foo.png|A foo]]  => A foo
+//	                       A foo
+//
+// Install it on a goldmark Renderer by using the WithNodeRenderers option.
+//
+//	textRenderer := util.Prioritized(&wikilink.TextRenderer{...}, 199)
+//	goldmarkRenderer.AddOptions(renderer.WithNodeRenderers(textRenderer))
+type TextRenderer struct {
+	// Resolver determines destinations for wikilink pages.
+	// It's used only if ShowDestination is set.
+	//
+	// Wikilinks already resolved by a BatchTransformer
+	// use that result instead.
+	//
+	// Defaults to DefaultResolver if unspecified.
+	Resolver Resolver
+
+	// ShowDestination specifies that the destination of a wikilink
+	// should be rendered after its label in parentheses.
+	//
+	//	[[Foo|bar]]  => bar (Foo.html)
+	//
+	// Wikilinks that do not resolve to a destination
+	// are rendered with just their labels.
+	ShowDestination bool
+}
+
+var _ renderer.NodeRenderer = (*TextRenderer)(nil)
+
+// RegisterFuncs registers wikilink rendering functions with the provided
+// goldmark registerer.
+func (r *TextRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(Kind, r.Render)
+	reg.Register(FigureKind, r.renderFigure)
+	reg.Register(WikiWordEscapeKind, renderChildren)
+}
+
+// Render renders the provided Node as plain text.
+// It must be a Wikilink [Node].
+func (r *TextRenderer) Render(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n, ok := node.(*Node)
+	if !ok {
+		return ast.WalkStop, fmt.Errorf("unexpected node %T, expected *wikilink.Node", node)
+	}
+
+	_, _ = w.Write(nodeText(src, n))
+	if !r.ShowDestination {
+		return ast.WalkSkipChildren, nil
+	}
+
+	dest, err := resolveNode(r.Resolver, n)
+	if err != nil {
+		return ast.WalkStop, fmt.Errorf("resolve %q: %w", n.Target, err)
+	}
+	if len(dest) > 0 {
+		_, _ = w.WriteString(" (")
+		_, _ = w.Write(dest)
+		_ = w.WriteByte(')')
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// renderFigure renders a Figure as its image,
+// followed by its caption on the next line if it has one.
+func (r *TextRenderer) renderFigure(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		return ast.WalkContinue, nil
+	}
+
+	if caption := figureCaption(src, node); len(caption) > 0 {
+		_ = w.WriteByte('\n')
+		_, _ = w.Write(caption)
+	}
+	_ = w.WriteByte('\n')
+	return ast.WalkContinue, nil
+}
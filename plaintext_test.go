@@ -0,0 +1,165 @@
+package wikilink
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+func TestTextRenderer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		renderer TextRenderer
+		give     string
+		want     string
+	}{
+		{
+			desc: "label",
+			give: "[[foo|bar]]",
+			want: "bar",
+		},
+		{
+			desc: "no label",
+			give: "[[foo#bar]]",
+			want: "foo#bar",
+		},
+		{
+			desc:     "destination",
+			renderer: TextRenderer{ShowDestination: true},
+			give:     "[[foo bar#baz|qux]]",
+			want:     "qux (foo bar.html#baz)",
+		},
+		{
+			desc:     "destination/embed",
+			renderer: TextRenderer{ShowDestination: true},
+			give:     "![[foo.png|a foo]]",
+			want:     "a foo (foo.png)",
+		},
+		{
+			desc: "destination/unresolved",
+			renderer: TextRenderer{
+				Resolver:        resolverFunc(noopResolver),
+				ShowDestination: true,
+			},
+			give: "[[foo|bar]]",
+			want: "bar",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			src := []byte(tt.give)
+			var p Parser
+			n := p.Parse(nil /* parent */, text.NewReader(src), parser.NewContext())
+			require.NotNil(t, n, "parse failed")
+
+			var buff bytes.Buffer
+			w := bufio.NewWriter(&buff)
+			status, err := tt.renderer.Render(w, src, n, true /* entering */)
+			require.NoError(t, err)
+			assert.Equal(t, ast.WalkSkipChildren, status)
+			_, err = tt.renderer.Render(w, src, n, false /* entering */)
+			require.NoError(t, err)
+			require.NoError(t, w.Flush())
+
+			assert.Equal(t, tt.want, buff.String())
+		})
+	}
+}
+
+func TestTextRenderer_figures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		renderer TextRenderer
+		give     string
+		want     string
+	}{
+		{
+			desc: "caption",
+			give: "![[foo.png|A foo]]\n",
+			want: "A foo\nA foo\n",
+		},
+		{
+			desc: "no caption",
+			give: "![[foo.png]]\n",
+			want: "foo.png\n",
+		},
+		{
+			desc:     "destination",
+			renderer: TextRenderer{ShowDestination: true},
+			give:     "![[foo.png|A foo]]\n",
+			want:     "A foo (foo.png)\nA foo\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, renderFigures(t, &tt.renderer, tt.give))
+		})
+	}
+}
+
+func TestTextRenderer_ResolveError(t *testing.T) {
+	t.Parallel()
+
+	r := TextRenderer{
+		ShowDestination: true,
+		Resolver: resolverFunc(func(*Node) ([]byte, error) {
+			return nil, errors.New("great sadness")
+		}),
+	}
+	n := &Node{Target: []byte("foo")}
+	_, err := r.Render(bufio.NewWriter(io.Discard), nil /* src */, n, true /* enter */)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "great sadness")
+}
+
+func TestTextRenderer_batchResolved(t *testing.T) {
+	t.Parallel()
+
+	r := TextRenderer{
+		ShowDestination: true,
+		Resolver: resolverFunc(func(*Node) ([]byte, error) {
+			t.Errorf("Resolver must not be called")
+			return nil, nil
+		}),
+	}
+
+	src := []byte("[[foo|bar]]")
+	n := &Node{Target: []byte("foo"), resolution: &resolution{dest: []byte("/foo")}}
+	n.AppendChild(n, ast.NewTextSegment(text.NewSegment(6, 9)))
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	_, err := r.Render(w, src, n, true /* enter */)
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "bar (/foo)", buf.String())
+}
+
+func TestTextRenderer_IncorrectNode(t *testing.T) {
+	t.Parallel()
+
+	var r TextRenderer
+	_, err := r.Render(bufio.NewWriter(io.Discard), nil /* src */, ast.NewText(), true /* enter */)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected node")
+}
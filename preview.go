@@ -0,0 +1,154 @@
+package wikilink
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/util"
+)
+
+// Preview holds information about the destination of a wikilink
+// to display when the link is hovered over.
+type Preview struct {
+	// Title of the linked page.
+	Title string `json:"title,omitempty"`
+
+	// Excerpt is a short summary of the linked page.
+	Excerpt string `json:"excerpt,omitempty"`
+
+	// Image is the address of an image representing the linked page.
+	Image string `json:"image,omitempty"`
+}
+
+// attributes returns data-preview-* attributes for the preview.
+// Empty fields are omitted.
+func (p *Preview) attributes() []ast.Attribute {
+	attrs := make([]ast.Attribute, 0, 3)
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"data-preview-title", p.Title},
+		{"data-preview-excerpt", p.Excerpt},
+		{"data-preview-image", p.Image},
+	} {
+		if field.value != "" {
+			attrs = append(attrs, ast.Attribute{
+				Name:  []byte(field.name),
+				Value: field.value,
+			})
+		}
+	}
+	return attrs
+}
+
+// Previewer provides previews of the destinations of wikilinks.
+//
+// If a Renderer has a Previewer,
+// it adds the following attributes to the <a> tags for wikilinks,
+// omitting those for empty fields of the Preview.
+//
+//	<a href="..."
+//	  data-preview-title="..."
+//	  data-preview-excerpt="..."
+//	  data-preview-image="...">
+//
+// Previews are not generated for embedded images.
+type Previewer interface {
+	// WikilinkPreview returns a preview of the destination
+	// of the provided wikilink.
+	// dest is the destination returned by the Resolver.
+	//
+	// If WikilinkPreview returns a nil Preview and error,
+	// the link is rendered without a preview.
+	// If it returns a non-nil error, rendering will be halted.
+	WikilinkPreview(n *Node, dest []byte) (*Preview, error)
+}
+
+// PreviewManifest collects previews for wikilinks
+// encountered while rendering a document.
+//
+// Attach a PreviewManifest to a conversion with RecordPreviews.
+//
+//	var manifest wikilink.PreviewManifest
+//	ctx := parser.NewContext()
+//	wikilink.RecordPreviews(ctx, &manifest)
+//	err := md.Convert(src, dst, parser.WithContext(ctx))
+//	// ...
+//	manifestJSON, err := json.Marshal(&manifest)
+//
+// A PreviewManifest is safe for concurrent use
+// so it may be shared between conversions of multiple documents.
+type PreviewManifest struct {
+	mu       sync.Mutex
+	previews map[string]Preview // URL-escaped destination => preview
+}
+
+var _ json.Marshaler = (*PreviewManifest)(nil)
+
+// RecordPreviews specifies that previews for wikilinks rendered during
+// the conversion using the provided parser.Context should be recorded to
+// the given PreviewManifest.
+//
+// Previews are recorded only if the Renderer has a Previewer.
+func RecordPreviews(pc parser.Context, m *PreviewManifest) {
+	conversionOf(pc).previews = m
+}
+
+// Previews returns a copy of the recorded previews,
+// keyed by the destinations of the links as they appear in href
+// attributes.
+func (m *PreviewManifest) Previews() map[string]Preview {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previews := make(map[string]Preview, len(m.previews))
+	for dest, p := range m.previews {
+		previews[dest] = p
+	}
+	return previews
+}
+
+// MarshalJSON encodes the recorded previews as a JSON object
+// keyed by the destinations of the links as they appear in href
+// attributes.
+//
+//	{
+//	  "Foo.html": {"title": "Foo", "excerpt": "...", "image": "..."},
+//	  ...
+//	}
+func (m *PreviewManifest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Previews())
+}
+
+func (m *PreviewManifest) record(dest []byte, p *Preview) {
+	key := string(util.URLEscape(dest, true /* resolve references */))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.previews == nil {
+		m.previews = make(map[string]Preview)
+	}
+	m.previews[key] = *p
+}
+
+// preview retrieves the preview for a link from the Previewer, if any,
+// and records it in the conversion's PreviewManifest.
+func (r *Renderer) preview(n *Node, dest []byte) (*Preview, error) {
+	if r.Previewer == nil {
+		return nil, nil
+	}
+
+	p, err := r.Previewer.WikilinkPreview(n, dest)
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	if n.conv != nil && n.conv.previews != nil {
+		n.conv.previews.record(dest, p)
+	}
+	return p, nil
+}
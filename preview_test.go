@@ -0,0 +1,107 @@
+package wikilink_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+type previewerFunc func(*wikilink.Node, []byte) (*wikilink.Preview, error)
+
+func (f previewerFunc) WikilinkPreview(n *wikilink.Node, dest []byte) (*wikilink.Preview, error) {
+	return f(n, dest)
+}
+
+var _previews = map[string]*wikilink.Preview{
+	"Foo": {
+		Title:   "Foo",
+		Excerpt: `Foo is a "thing".`,
+		Image:   "foo.png",
+	},
+	"Bar baz": {
+		Title: "Bar Baz",
+	},
+}
+
+var _previewer = previewerFunc(func(n *wikilink.Node, _ []byte) (*wikilink.Preview, error) {
+	return _previews[string(n.Target)], nil
+})
+
+func TestPreviews(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Resolver:  _resolver,
+		Previewer: _previewer,
+	}))
+
+	src := "[[Foo]], [[Bar baz|bar]], [[Qux]], [[Does Not Exist]], ![[Foo.png]]\n"
+
+	var manifest wikilink.PreviewManifest
+	pc := parser.NewContext()
+	wikilink.RecordPreviews(pc, &manifest)
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte(src), &buf, parser.WithContext(pc)))
+	assert.Equal(t,
+		`<p><a href="Foo.html" data-preview-title="Foo"`+
+			` data-preview-excerpt="Foo is a &quot;thing&quot;."`+
+			` data-preview-image="foo.png">Foo</a>, `+
+			`<a href="Bar%20baz.html" data-preview-title="Bar Baz">bar</a>, `+
+			`<a href="Qux.html">Qux</a>, `+
+			`Does Not Exist, `+
+			`<img src="Foo.png"></p>`+"\n",
+		buf.String())
+
+	assert.Equal(t, map[string]wikilink.Preview{
+		"Foo.html":       *_previews["Foo"],
+		"Bar%20baz.html": *_previews["Bar baz"],
+	}, manifest.Previews())
+
+	got, err := json.Marshal(&manifest)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"Bar%20baz.html": {"title": "Bar Baz"},
+		"Foo.html": {
+			"title": "Foo",
+			"excerpt": "Foo is a \"thing\".",
+			"image": "foo.png"
+		}
+	}`, string(got))
+}
+
+func TestPreviews_noManifest(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Previewer: _previewer,
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, md.Convert([]byte("[[Bar baz]]"), &buf))
+	assert.Equal(t,
+		`<p><a href="Bar%20baz.html" data-preview-title="Bar Baz">Bar baz</a></p>`+"\n",
+		buf.String())
+}
+
+func TestPreviews_error(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Previewer: previewerFunc(func(*wikilink.Node, []byte) (*wikilink.Preview, error) {
+			return nil, errors.New("great sadness")
+		}),
+	}))
+
+	var buf bytes.Buffer
+	err := md.Convert([]byte("[[Foo]]"), &buf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "great sadness")
+}
@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
-	"sync"
 
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/util"
 )
 
@@ -19,6 +19,12 @@ import (
 //
 //	wikilinkRenderer := util.Prioritized(&wikilink.Renderer{...}, 199)
 //	goldmarkRenderer.AddOptions(renderer.WithNodeRenderers(wikilinkRenderer))
+//
+// A Renderer is safe for concurrent use by multiple goroutines
+// as long as its fields are not modified after the first render,
+// and its Resolver, Attributer, and Previewer are safe for concurrent use.
+// Use SetContext to pass per-conversion state to a ContextResolver
+// instead of storing it on a shared Resolver.
 type Renderer struct {
 	// Resolver determines destinations for wikilink pages.
 	//
@@ -34,20 +40,58 @@ type Renderer struct {
 	// Defaults to DefaultResolver if unspecified.
 	Resolver Resolver
 
-	once sync.Once // guards init
+	// Attributer computes additional HTML attributes
+	// for the tags generated for wikilinks.
+	//
+	// Attributes set on the Node with SetAttribute are always rendered.
+	Attributer Attributer
+
+	// Templates specifies templates to render wikilinks with
+	// instead of the default HTML.
+	//
+	// See Templates for details.
+	Templates *Templates
+
+	// Previewer provides previews of linked pages
+	// to render as data-preview-* attributes on links.
+	//
+	// See Previewer for details.
+	Previewer Previewer
+
+	// ErrorPolicy specifies how errors returned by the Resolver
+	// are handled.
+	//
+	// Defaults to AbortOnError.
+	ErrorPolicy ErrorPolicy
+
+	// BrokenLinkClass is the class of links rendered by
+	// the RenderErrorAsBrokenLink policy.
+	//
+	// Defaults to DefaultBrokenLinkClass if unspecified.
+	BrokenLinkClass string
 
-	// hasDest records whether a node had a destination when we resolved
-	// it. This is needed to decide whether a closing </a> must be added
-	// when exiting a Node render.
-	hasDest sync.Map // *Node => struct{}
+	// config holds options for goldmark's HTML renderer
+	// that the Renderer respects.
+	//
+	// Wikilinks cannot span lines so HardWraps and EastAsianLineBreaks
+	// are not relevant to the Renderer.
+	config html.Config
 }
 
-func (r *Renderer) init() {
-	r.once.Do(func() {
-		if r.Resolver == nil {
-			r.Resolver = DefaultResolver
-		}
-	})
+var _ renderer.SetOptioner = (*Renderer)(nil)
+
+// SetOption receives options from the goldmark Renderer.
+// This teaches the Renderer to respect goldmark's HTML rendering options.
+//
+//   - html.WithXHTML: render image embeds as "<img ... />"
+//   - html.WithUnsafe: allow destinations with potentially dangerous URL
+//     schemes like "javascript:"
+//
+// Without WithUnsafe, links to potentially dangerous destinations
+// are rendered with an empty href or src, matching goldmark's own
+// link and image renderers.
+func (r *Renderer) SetOption(name renderer.OptionName, value interface{}) {
+	r.config.SetOption(name, value)
 }
 
 // RegisterFuncs registers wikilink rendering functions with the provided
@@ -55,6 +99,8 @@ func (r *Renderer) init() {
 // wikilink in the AST.
 func (r *Renderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
 	reg.Register(Kind, r.Render)
+	reg.Register(FigureKind, r.renderFigure)
+	reg.Register(WikiWordEscapeKind, renderChildren)
 }
 
 // Render renders the provided Node. It must be a Wikilink [Node].
@@ -66,8 +112,6 @@ func (r *Renderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
 // except for embed links (![[..]]) that refer to images.
 // Those will be rendered as images (with <img> tags).
 func (r *Renderer) Render(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-	r.init()
-
 	n, ok := node.(*Node)
 	if !ok {
 		return ast.WalkStop, fmt.Errorf("unexpected node %T, expected *wikilink.Node", node)
@@ -82,9 +126,37 @@ func (r *Renderer) Render(w util.BufWriter, src []byte, node ast.Node, entering
 }
 
 func (r *Renderer) enter(w util.BufWriter, n *Node, src []byte) (ast.WalkStatus, error) {
-	dest, err := r.Resolver.ResolveWikilink(n)
+	// Reset state left behind by a previous render of this node
+	// that was aborted before the node was exited.
+	n.open = false
+
+	// Links cannot be nested in HTML,
+	// so wikilinks nested inside rendered links (see LogseqDialect)
+	// are rendered as they were written.
+	if insideLink(n) {
+		_, _ = w.Write(util.EscapeHTML(n.Segment.Value(src)))
+		return ast.WalkSkipChildren, nil
+	}
+
+	dest, err := r.resolve(n)
 	if err != nil {
-		return ast.WalkStop, fmt.Errorf("resolve %q: %w", n.Target, err)
+		// Canceled conversions must not continue regardless of policy.
+		if ctxErr := n.context().Err(); ctxErr != nil {
+			return ast.WalkStop, newResolveError(src, n, ctxErr)
+		}
+
+		status, handled, err := r.resolveFailed(w, src, n, err)
+		if handled {
+			return status, err
+		}
+		dest = nil
+	} else if len(dest) == 0 {
+		diagnose(src, n, nil)
+	}
+	if r.Templates != nil {
+		if tmpl := r.Templates.template(n, dest); tmpl != nil {
+			return r.renderTemplate(w, src, tmpl, n, dest)
+		}
 	}
 	if len(dest) == 0 {
 		return ast.WalkContinue, nil
@@ -92,15 +164,22 @@ func (r *Renderer) enter(w util.BufWriter, n *Node, src []byte) (ast.WalkStatus,
 
 	img := resolveAsImage(n)
 	if !img {
-		r.hasDest.Store(n, struct{}{})
+		preview, err := r.preview(n, dest)
+		if err != nil {
+			return ast.WalkStop, fmt.Errorf("preview %q: %w", n.Target, err)
+		}
+
+		n.open = true
 		_, _ = w.WriteString(`<a href="`)
-		_, _ = w.Write(util.URLEscape(dest, true /* resolve references */))
-		_, _ = w.WriteString(`">`)
+		r.writeDestination(w, dest)
+		_ = w.WriteByte('"')
+		renderAttributes(w, r.attributes(n, dest, preview), html.LinkAttributeFilter)
+		_ = w.WriteByte('>')
 		return ast.WalkContinue, nil
 	}
 
 	_, _ = w.WriteString(`<img src="`)
-	_, _ = w.Write(util.URLEscape(dest, true /* resolve references */))
+	r.writeDestination(w, dest)
 	// The label portion of the link becomes the alt text
 	// only if it isn't the same as the target.
 	// This way, [[foo.jpg]] does not become alt="foo.jpg",
@@ -112,12 +191,66 @@ func (r *Renderer) enter(w util.BufWriter, n *Node, src []byte) (ast.WalkStatus,
 			_, _ = w.Write(util.EscapeHTML(label))
 		}
 	}
-	_, _ = w.WriteString(`">`)
+	_ = w.WriteByte('"')
+	renderAttributes(w, r.attributes(n, dest, nil /* preview */), html.ImageAttributeFilter)
+	if r.config.XHTML {
+		_, _ = w.WriteString(" />")
+	} else {
+		_ = w.WriteByte('>')
+	}
 	return ast.WalkSkipChildren, nil
 }
 
+// writeDestination writes the escaped destination for a link or image
+// unless it's potentially dangerous and unsafe rendering is not enabled.
+func (r *Renderer) writeDestination(w util.BufWriter, dest []byte) {
+	// All dangerous URLs have a scheme.
+	// Check for that first because IsDangerousURL allocates.
+	if r.config.Unsafe || bytes.IndexByte(dest, ':') < 0 || !html.IsDangerousURL(dest) {
+		_, _ = w.Write(util.EscapeHTML(util.URLEscape(dest, true /* resolve references */)))
+	}
+}
+
+// resolve returns the destination for the provided Node,
+// reusing the resolution made by its Figure if there is one.
+func (r *Renderer) resolve(n *Node) ([]byte, error) {
+	if res := lookahead(n); res != nil {
+		return res.dest, res.err
+	}
+	return resolveNode(r.Resolver, n)
+}
+
+// resolveNode returns the destination for the provided Node,
+// using the result from the BatchTransformer if available.
+// Otherwise, it resolves the Node with the provided Resolver,
+// or DefaultResolver if it's nil.
+func resolveNode(resolver Resolver, n *Node) ([]byte, error) {
+	if res := n.resolution; res != nil {
+		if err := n.context().Err(); err != nil {
+			return nil, err
+		}
+		return res.dest, res.err
+	}
+	if resolver == nil {
+		resolver = DefaultResolver
+	}
+	return resolve(resolver, n)
+}
+
+// insideLink reports whether the provided Node is nested inside
+// another wikilink that was rendered as a link.
+func insideLink(n *Node) bool {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if outer, ok := p.(*Node); ok && outer.open {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Renderer) exit(w util.BufWriter, n *Node) {
-	if _, ok := r.hasDest.LoadAndDelete(n); ok {
+	if n.open {
+		n.open = false
 		_, _ = w.WriteString("</a>")
 	}
 }
@@ -140,6 +273,17 @@ func resolveAsImage(n *Node) bool {
 }
 
 func nodeText(src []byte, n ast.Node) []byte {
+	// Most labels are a single text segment.
+	// Avoid copying those.
+	if n.ChildCount() == 1 {
+		if t, ok := n.FirstChild().(*ast.Text); ok {
+			return t.Segment.Value(src)
+		}
+	}
+	if t, ok := n.(*ast.Text); ok {
+		return t.Segment.Value(src)
+	}
+
 	var buf bytes.Buffer
 	writeNodeText(src, &buf, n)
 	return buf.Bytes()
@@ -153,6 +297,11 @@ func writeNodeText(src []byte, dst io.Writer, n ast.Node) {
 		_, _ = dst.Write(n.Value)
 	default:
 		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			// Nested wikilinks are part of the label as written.
+			if nested, ok := c.(*Node); ok && nested.Segment.Len() > 0 {
+				_, _ = dst.Write(nested.Segment.Value(src))
+				continue
+			}
 			writeNodeText(src, dst, c)
 		}
 	}
@@ -9,7 +9,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 )
 
 func TestRenderer(t *testing.T) {
@@ -204,3 +208,121 @@ func TestRenderer_ResolveError(t *testing.T) {
 func noopResolver(*Node) ([]byte, error) {
 	return nil, nil
 }
+
+func TestRenderer_HTMLOptions(t *testing.T) {
+	t.Parallel()
+
+	jsResolver := resolverFunc(func(n *Node) ([]byte, error) {
+		if string(n.Target) == "evil" {
+			return []byte("javascript:alert(1)"), nil
+		}
+		return DefaultResolver.ResolveWikilink(n)
+	})
+
+	tests := []struct {
+		desc     string
+		opts     []renderer.Option
+		resolver Resolver // defaults to jsResolver
+		give     string
+		want     string
+	}{
+		{
+			desc: "image",
+			give: "![[foo.png|bar]]",
+			want: `<p><img src="foo.png" alt="bar"></p>`,
+		},
+		{
+			desc: "image/xhtml",
+			opts: []renderer.Option{html.WithXHTML()},
+			give: "![[foo.png|bar]]",
+			want: `<p><img src="foo.png" alt="bar" /></p>`,
+		},
+		{
+			desc: "dangerous link",
+			give: "[[evil|click me]]",
+			want: `<p><a href="">click me</a></p>`,
+		},
+		{
+			desc: "dangerous link/unsafe",
+			opts: []renderer.Option{html.WithUnsafe()},
+			give: "[[evil|click me]]",
+			want: `<p><a href="javascript:alert(1)">click me</a></p>`,
+		},
+		{
+			desc: "dangerous image",
+			resolver: resolverFunc(func(*Node) ([]byte, error) {
+				return []byte("vbscript:foo.png"), nil
+			}),
+			give: "![[evil.png]]",
+			want: `<p><img src=""></p>`,
+		},
+		{
+			desc: "safe data image",
+			give: "![[data:image/png;base64,AAAA.png]]",
+			want: `<p><img src="data:image/png;base64,AAAA.png"></p>`,
+		},
+		{
+			desc: "escaped destination",
+			give: "[[a&b]]",
+			want: `<p><a href="a&amp;b.html">a&amp;b</a></p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			resolver := tt.resolver
+			if resolver == nil {
+				resolver = jsResolver
+			}
+
+			md := goldmark.New(
+				goldmark.WithExtensions(&Extender{Resolver: resolver}),
+				goldmark.WithRendererOptions(tt.opts...),
+			)
+
+			var buf bytes.Buffer
+			require.NoError(t, md.Convert([]byte(tt.give), &buf))
+			assert.Equal(t, tt.want+"\n", buf.String())
+		})
+	}
+}
+
+func TestRenderer_abortedRender(t *testing.T) {
+	t.Parallel()
+
+	n := &Node{Target: []byte("foo")}
+
+	// Enter the node without exiting it
+	// as happens when a render is aborted.
+	var r Renderer
+	_, err := r.Render(bufio.NewWriter(io.Discard), nil /* source */, n, true /* entering */)
+	require.NoError(t, err)
+
+	// A later render must not be affected by the aborted one.
+	r2 := Renderer{Resolver: resolverFunc(noopResolver)}
+	var buff bytes.Buffer
+	w := bufio.NewWriter(&buff)
+	_, err = r2.Render(w, nil /* source */, n, true /* entering */)
+	require.NoError(t, err)
+	_, err = r2.Render(w, nil /* source */, n, false /* entering */)
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	assert.Empty(t, buff.String())
+}
+
+func BenchmarkRenderer(b *testing.B) {
+	md := goldmark.New(goldmark.WithExtensions(&Extender{}))
+	for _, corpus := range _benchmarkCorpora {
+		doc := md.Parser().Parse(text.NewReader(corpus.src))
+		b.Run(corpus.name, func(b *testing.B) {
+			reportAllocsPerLink(b, corpus.links, func() {
+				if err := md.Renderer().Render(io.Discard, corpus.src, doc); err != nil {
+					b.Fatal(err)
+				}
+			})
+		})
+	}
+}
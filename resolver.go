@@ -1,6 +1,11 @@
 package wikilink
 
-import "path/filepath"
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+)
 
 // DefaultResolver is a minimal wikilink resolver that resolves wikilinks
 // relative to the source page.
@@ -15,16 +20,26 @@ import "path/filepath"
 //	[[foo/Bar]]  // => "foo/Bar.html"
 //	[[foo.pdf]]  // => "foo.pdf"
 //	[[foo.png]]  // => "foo.png"
-var DefaultResolver Resolver = defaultResolver{}
+//
+// DefaultResolver is a PathResolver with PageSuffix set to ".html".
+// Use a PathResolver directly to change its behavior.
+var DefaultResolver Resolver = &PathResolver{PageSuffix: ".html"}
 
 // Resolver resolves pages referenced by wikilinks to their destinations.
+//
+// Resolvers used with a goldmark Markdown object
+// that converts documents concurrently
+// must be safe for concurrent use by multiple goroutines.
+// Resolvers that need per-conversion state, like the current request,
+// should implement ContextResolver and read that state from the context
+// provided with SetContext.
 type Resolver interface {
 	// ResolveWikilink returns the address of the page that the provided
 	// wikilink points to. The destination will be URL-escaped before
 	// being placed into a link.
 	//
 	// If ResolveWikilink returns a non-nil error, rendering will be
-	// halted.
+	// halted unless the Renderer's ErrorPolicy specifies otherwise.
 	//
 	// If ResolveWikilink returns a nil destination and error, the
 	// Renderer will omit the link and render its contents as a regular
@@ -32,22 +47,151 @@ type Resolver interface {
 	ResolveWikilink(*Node) (destination []byte, err error)
 }
 
-var _html = []byte(".html")
+// ContextResolver is a Resolver that accepts a context.Context.
+//
+// Resolvers that perform I/O or other long-running operations
+// should implement this interface.
+// The Renderer calls ResolveWikilinkContext instead of ResolveWikilink
+// for resolvers that implement it,
+// passing in the context specified for the conversion with SetContext.
+// If no context was specified, context.Background() is used.
+type ContextResolver interface {
+	Resolver
 
-type defaultResolver struct{}
+	// ResolveWikilinkContext is a variant of ResolveWikilink
+	// that accepts a context.Context.
+	// It should stop early and return the context's error
+	// if the context is canceled or its deadline expires.
+	ResolveWikilinkContext(ctx context.Context, n *Node) (destination []byte, err error)
+}
 
-func (defaultResolver) ResolveWikilink(n *Node) ([]byte, error) {
-	dest := make([]byte, len(n.Target)+len(_html)+len(_hash)+len(n.Fragment))
-	var i int
-	if len(n.Target) > 0 {
-		i += copy(dest, n.Target)
-		if filepath.Ext(string(n.Target)) == "" {
-			i += copy(dest[i:], _html)
-		}
+// resolve resolves the provided wikilink with the given Resolver,
+// passing in the conversion's context if the Resolver accepts one.
+//
+// If the conversion's context is done,
+// resolve returns its error without calling the Resolver.
+func resolve(r Resolver, n *Node) ([]byte, error) {
+	ctx := n.context()
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+
+	if cr, ok := r.(ContextResolver); ok {
+		return cr.ResolveWikilinkContext(ctx, n)
+	}
+	return r.ResolveWikilink(n)
+}
+
+// PathResolver resolves wikilinks to paths built from their targets.
+// Targets that refer to pages get a suffix like ".html",
+// and targets that refer to attachments like images are used as-is.
+//
+//	&PathResolver{PageSuffix: ".html"} // [[Foo]] => "Foo.html"
+//	&PathResolver{PageSuffix: "/"}     // [[Foo]] => "Foo/"
+//	&PathResolver{}                    // [[Foo]] => "Foo"
+//
+// A PathResolver is safe for concurrent use
+// if its fields are not modified after first use.
+type PathResolver struct {
+	// BasePath is prepended to the destinations of all targets.
+	// Include a trailing "/" to place destinations inside a directory.
+	//
+	//	&PathResolver{BasePath: "/wiki/"} // [[Foo]] => "/wiki/Foo"
+	//
+	// Links to fragments in the same page like [[#Foo]]
+	// don't use the BasePath.
+	BasePath string
+
+	// PageSuffix is appended to targets that refer to pages.
+	// For example, ".html" or "/".
+	//
+	// No suffix is appended if unspecified.
+	PageSuffix string
+
+	// AttachmentExtensions lists the extensions of targets
+	// that refer to attachments rather than pages,
+	// including the leading ".". For example, ".png" or ".pdf".
+	// Extensions are compared case-insensitively.
+	//
+	// Set this to DefaultAttachmentExtensions() for targets like
+	// [[v1.2 release]] or [[Mr. Smith]] that contain dots
+	// but are not file names.
+	//
+	// If unspecified, all targets with an extension are attachments.
+	AttachmentExtensions []string
+}
+
+var _ Resolver = (*PathResolver)(nil)
+
+// ResolveWikilink returns the destination for the provided wikilink.
+func (r *PathResolver) ResolveWikilink(n *Node) ([]byte, error) {
+	var suffix string
+	if len(n.Target) > 0 && !r.isAttachment(n.Target) {
+		suffix = r.PageSuffix
+	}
+	return resolvePath(n, r.BasePath, suffix), nil
+}
+
+func (r *PathResolver) isAttachment(target []byte) bool {
+	if r.AttachmentExtensions == nil {
+		return path.Ext(string(target)) != ""
+	}
+	return hasExtension(target, r.AttachmentExtensions)
+}
+
+// resolvePath builds the destination for a wikilink
+// from its target and fragment.
+//
+//	base + target + suffix + "#" + fragment
+//
+// The base and suffix are omitted if the target is empty.
+func resolvePath(n *Node, base, suffix string) []byte {
+	if len(n.Target) == 0 {
+		base = ""
+	}
+
+	dest := make([]byte, 0, len(base)+len(n.Target)+len(suffix)+len(_hash)+len(n.Fragment))
+	dest = append(dest, base...)
+	dest = append(dest, n.Target...)
+	dest = append(dest, suffix...)
 	if len(n.Fragment) > 0 {
-		i += copy(dest[i:], _hash)
-		i += copy(dest[i:], n.Fragment)
+		dest = append(dest, _hash...)
+		dest = append(dest, n.Fragment...)
+	}
+	return dest
+}
+
+// _attachmentExtensions are the extensions returned by
+// DefaultAttachmentExtensions.
+var _attachmentExtensions = []string{
+	// Images.
+	".apng", ".avif", ".bmp", ".gif", ".ico", ".jpg", ".jpeg", ".jfif",
+	".pjpeg", ".pjp", ".png", ".svg", ".tif", ".tiff", ".webp",
+	// Audio and video.
+	".flac", ".m4a", ".mp3", ".ogg", ".wav",
+	".m4v", ".mkv", ".mov", ".mp4", ".ogv", ".webm",
+	// Documents and archives.
+	".csv", ".docx", ".epub", ".pdf", ".pptx", ".xlsx", ".zip",
+}
+
+// DefaultAttachmentExtensions returns the extensions of common image,
+// audio, video, and document files like ".png", ".mp3", and ".pdf".
+// The returned slice may be modified.
+func DefaultAttachmentExtensions() []string {
+	return slices.Clone(_attachmentExtensions)
+}
+
+// hasExtension reports whether the target ends with one of the extensions.
+// Extensions are compared case-insensitively.
+func hasExtension(target []byte, exts []string) bool {
+	ext := path.Ext(string(target))
+	if ext == "" {
+		return false
+	}
+	for _, e := range exts {
+		if strings.EqualFold(ext, e) {
+			return true
+		}
 	}
-	return dest[:i], nil
+	return false
 }
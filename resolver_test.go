@@ -73,3 +73,154 @@ func TestDefaultResolver(t *testing.T) {
 		})
 	}
 }
+
+func TestPathResolver(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		resolver *PathResolver
+		target   string
+		fragment string
+		want     string
+	}{
+		{
+			desc:     "zero",
+			resolver: &PathResolver{},
+			target:   "foo",
+			want:     "foo",
+		},
+		{
+			desc:     "directory suffix",
+			resolver: &PathResolver{PageSuffix: "/"},
+			target:   "foo bar",
+			fragment: "baz",
+			want:     "foo bar/#baz",
+		},
+		{
+			desc:     "directory suffix attachment",
+			resolver: &PathResolver{PageSuffix: "/"},
+			target:   "foo.png",
+			want:     "foo.png",
+		},
+		{
+			desc:     "dot in directory",
+			resolver: &PathResolver{PageSuffix: ".html"},
+			target:   `v1.2\notes/page`,
+			want:     `v1.2\notes/page.html`,
+		},
+		{
+			desc:     "base path",
+			resolver: &PathResolver{BasePath: "/wiki/", PageSuffix: ".html"},
+			target:   "foo/bar",
+			want:     "/wiki/foo/bar.html",
+		},
+		{
+			desc:     "base path attachment",
+			resolver: &PathResolver{BasePath: "/wiki/", PageSuffix: ".html"},
+			target:   "foo.pdf",
+			want:     "/wiki/foo.pdf",
+		},
+		{
+			desc:     "base path fragment only",
+			resolver: &PathResolver{BasePath: "/wiki/", PageSuffix: ".html"},
+			fragment: "foo",
+			want:     "#foo",
+		},
+		{
+			desc:     "any extension",
+			resolver: &PathResolver{PageSuffix: ".html"},
+			target:   "v1.2 release",
+			want:     "v1.2 release",
+		},
+		{
+			desc: "known extensions/version",
+			resolver: &PathResolver{
+				PageSuffix:           ".html",
+				AttachmentExtensions: DefaultAttachmentExtensions(),
+			},
+			target: "v1.2 release",
+			want:   "v1.2 release.html",
+		},
+		{
+			desc: "known extensions/abbreviation",
+			resolver: &PathResolver{
+				PageSuffix:           ".html",
+				AttachmentExtensions: DefaultAttachmentExtensions(),
+			},
+			target: "Mr. Smith",
+			want:   "Mr. Smith.html",
+		},
+		{
+			desc: "known extensions/attachment",
+			resolver: &PathResolver{
+				PageSuffix:           ".html",
+				AttachmentExtensions: DefaultAttachmentExtensions(),
+			},
+			target: "photos/Cat.JPG",
+			want:   "photos/Cat.JPG",
+		},
+		{
+			desc: "custom extensions",
+			resolver: &PathResolver{
+				PageSuffix:           ".html",
+				AttachmentExtensions: []string{".drawio"},
+			},
+			target: "arch.drawio",
+			want:   "arch.drawio",
+		},
+		{
+			desc: "no extensions",
+			resolver: &PathResolver{
+				PageSuffix:           ".html",
+				AttachmentExtensions: []string{},
+			},
+			target: "foo.png",
+			want:   "foo.png.html",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.resolver.ResolveWikilink(&Node{
+				Target:   []byte(tt.target),
+				Fragment: []byte(tt.fragment),
+			})
+			require.NoError(t, err, "resolve failed")
+			assert.Equal(t, tt.want, string(got), "result mismatch")
+		})
+	}
+}
+
+func TestDefaultAttachmentExtensions(t *testing.T) {
+	t.Parallel()
+
+	exts := DefaultAttachmentExtensions()
+	assert.Contains(t, exts, ".png")
+	assert.Contains(t, exts, ".pdf")
+
+	exts[0] = ".modified"
+	assert.NotContains(t, DefaultAttachmentExtensions(), ".modified",
+		"must return a copy")
+}
+
+func BenchmarkDefaultResolver(b *testing.B) {
+	nodes := []*Node{
+		{Target: []byte("foo")},
+		{Target: []byte("foo/bar baz"), Fragment: []byte("qux")},
+		{Target: []byte("foo.png"), Embed: true},
+		{Fragment: []byte("foo")},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, n := range nodes {
+			if _, err := DefaultResolver.ResolveWikilink(n); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package wikilink
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// Templates specifies html/template templates that the Renderer uses
+// to render wikilinks instead of generating HTML itself.
+// This allows changing the markup for wikilinks without code changes.
+//
+// Each template is executed with a [TemplateData] for the wikilink.
+// For example,
+//
+//	<a class="wikilink" href="{{.Destination}}">{{.Label}}</a>
+//
+// html/template escapes values based on where they're placed in the
+// template, so destinations and labels are safe to use as-is.
+// Destinations with unsafe URL schemes, such as "javascript:",
+// are replaced with "#ZgotmplZ" by html/template.
+//
+// Wikilinks of a kind without a template are rendered by the Renderer
+// as usual.
+type Templates struct {
+	// Link renders wikilinks that are not embeds.
+	//
+	//	[[foo]]
+	Link *template.Template
+
+	// Image renders embeds that refer to images.
+	//
+	//	![[foo.png]]
+	Image *template.Template
+
+	// Embed renders embeds that do not refer to images.
+	//
+	//	![[foo.pdf]]
+	Embed *template.Template
+
+	// Unresolved renders wikilinks for which the Resolver
+	// did not return a destination.
+	Unresolved *template.Template
+}
+
+// TemplateData is the data passed to [Templates] to render a wikilink.
+type TemplateData struct {
+	// Target is the page to which the wikilink points.
+	Target string
+
+	// Fragment is the portion of the target after the "#", if any.
+	Fragment string
+
+	// Label is the text of the wikilink.
+	Label string
+
+	// Destination is the destination returned by the Resolver.
+	//
+	// This is empty for the Unresolved template.
+	Destination string
+
+	// Embed is true for embedded wikilinks (![[...]]).
+	Embed bool
+
+	// Image is true for embedded wikilinks that refer to images.
+	Image bool
+
+	// Attributes holds the attributes set on the Node with SetAttribute,
+	// and those returned by the Renderer's Attributer.
+	//
+	// Use this to access individual attributes.
+	//
+	//	<a class="{{index .Attributes "class"}}" ...>
+	Attributes map[string]string
+
+	// HTMLAttributes holds the same attributes as Attributes
+	// rendered and escaped for use inside a tag.
+	// As with the default HTML, only attributes that are valid for
+	// the generated tag are included.
+	//
+	// Use this to render all attributes at once.
+	// html/template does not allow attribute names to come from
+	// template data otherwise.
+	//
+	//	<a href="{{.Destination}}"{{.HTMLAttributes}}>
+	HTMLAttributes template.HTMLAttr
+
+	// Preview is the preview of the linked page
+	// if the Renderer has a Previewer.
+	// The data-preview-* attributes for it are included in Attributes.
+	//
+	// This is always nil for images.
+	Preview *Preview
+}
+
+// template returns the template for the provided wikilink,
+// or nil if the wikilink should be rendered without a template.
+func (ts *Templates) template(n *Node, dest []byte) *template.Template {
+	switch {
+	case len(dest) == 0:
+		return ts.Unresolved
+	case !n.Embed:
+		return ts.Link
+	case resolveAsImage(n):
+		return ts.Image
+	default:
+		return ts.Embed
+	}
+}
+
+func (r *Renderer) renderTemplate(
+	w util.BufWriter, src []byte, tmpl *template.Template, n *Node, dest []byte,
+) (ast.WalkStatus, error) {
+	data := TemplateData{
+		Target:      string(n.Target),
+		Fragment:    string(n.Fragment),
+		Label:       string(nodeText(src, n)),
+		Destination: string(dest),
+		Embed:       n.Embed,
+		Image:       n.Embed && resolveAsImage(n),
+	}
+	if len(dest) > 0 {
+		filter := html.ImageAttributeFilter
+		if !data.Image {
+			filter = html.LinkAttributeFilter
+
+			var err error
+			data.Preview, err = r.preview(n, dest)
+			if err != nil {
+				return ast.WalkStop, fmt.Errorf("preview %q: %w", n.Target, err)
+			}
+		}
+
+		attrs := r.attributes(n, dest, data.Preview)
+		data.Attributes = attributeMap(attrs)
+
+		var buf bytes.Buffer
+		bw := bufio.NewWriter(&buf)
+		renderAttributes(bw, attrs, filter)
+		_ = bw.Flush()
+		data.HTMLAttributes = template.HTMLAttr(buf.String())
+	}
+
+	if err := tmpl.Execute(w, &data); err != nil {
+		return ast.WalkStop, fmt.Errorf("render %q: %w", n.Target, err)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// attributeMap returns the provided attributes as a map.
+// Attributes with values that are not strings or byte slices are skipped.
+func attributeMap(attrs []ast.Attribute) map[string]string {
+	m := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		switch v := attr.Value.(type) {
+		case []byte:
+			m[string(attr.Name)] = string(v)
+		case string:
+			m[string(attr.Name)] = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,113 @@
+package wikilink_test
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+func TestTemplates(t *testing.T) {
+	t.Parallel()
+
+	tmpls := &wikilink.Templates{
+		Link: template.Must(template.New("link").Parse(
+			`<a class="wikilink" href="{{.Destination}}"{{.HTMLAttributes}}>{{.Label}}</a>`,
+		)),
+		Image: template.Must(template.New("image").Parse(
+			`<img src="{{.Destination}}" alt="{{.Label}}" data-target="{{index .Attributes "data-target"}}">`,
+		)),
+		Unresolved: template.Must(template.New("unresolved").Parse(
+			`<span class="missing" title="{{.Target}}#{{.Fragment}}">{{.Label}}</span>`,
+		)),
+	}
+
+	tests := []struct {
+		desc string
+		give string
+		want string
+	}{
+		{
+			desc: "link",
+			give: "[[foo]]",
+			want: `<a class="wikilink" href="foo.html" data-target="foo">foo</a>`,
+		},
+		{
+			desc: "link/label",
+			give: "[[foo bar|baz & qux]]",
+			want: `<a class="wikilink" href="foo%20bar.html" data-target="foo bar">baz &amp; qux</a>`,
+		},
+		{
+			desc: "link/escaped label",
+			give: "[[foo|<script>alert(1)</script>]]",
+			want: `<a class="wikilink" href="foo.html" data-target="foo">` +
+				`&lt;script&gt;alert(1)&lt;/script&gt;</a>`,
+		},
+		{
+			desc: "link/unsafe destination",
+			give: "[[javascript:alert(1)//]]",
+			want: `<a class="wikilink" href="#ZgotmplZ" data-target="javascript:alert(1)//">` +
+				`javascript:alert(1)//</a>`,
+		},
+		{
+			desc: "image",
+			give: "![[foo.png|a foo]]",
+			want: `<img src="foo.png" alt="a foo" data-target="foo.png">`,
+		},
+		{
+			desc: "embed/no template",
+			give: "![[foo.pdf|a foo]]",
+			want: `<a href="foo.pdf" data-target="foo.pdf">a foo</a>`,
+		},
+		{
+			desc: "unresolved",
+			give: "[[Does Not Exist#Bar|baz]]",
+			want: `<span class="missing" title="Does Not Exist#Bar">baz</span>`,
+		},
+	}
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Resolver:  _resolver,
+		Templates: tmpls,
+		Attributer: attributerFunc(func(n *wikilink.Node, _ []byte) []ast.Attribute {
+			return []ast.Attribute{{Name: []byte("data-target"), Value: n.Target}}
+		}),
+	}))
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			require.NoError(t, md.Convert([]byte(tt.give), &buf))
+			assert.Equal(t, "<p>"+tt.want+"</p>\n", buf.String())
+		})
+	}
+}
+
+func TestTemplates_executeError(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(&wikilink.Extender{
+		Templates: &wikilink.Templates{
+			Link: template.Must(template.New("link").Parse(`{{.DoesNotExist}}`)),
+		},
+	}))
+
+	var buf bytes.Buffer
+	err := md.Convert([]byte("[[foo]]"), &buf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `render "foo"`)
+}
+
+type attributerFunc func(*wikilink.Node, []byte) []ast.Attribute
+
+func (f attributerFunc) WikilinkAttributes(n *wikilink.Node, dest []byte) []ast.Attribute {
+	return f(n, dest)
+}
@@ -0,0 +1,232 @@
+package wikilink
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// WikiWordEscapeKind is the kind of the WikiWordEscape AST node.
+var WikiWordEscapeKind = ast.NewNodeKind("WikiWordEscape")
+
+// WikiWordEscape is an inline AST node for a WikiWord
+// that was prefixed with "!" to keep it from becoming a link.
+//
+//	!NotLinked
+//
+// It has a single Text child with the WikiWord without the "!".
+// Renderer and TextRenderer render only the WikiWord,
+// and MarkdownRenderer renders it with the "!"
+// so that it stays escaped.
+type WikiWordEscape struct {
+	ast.BaseInline
+
+	// Segment is the position of the escaped WikiWord in the source,
+	// including the "!".
+	Segment text.Segment
+}
+
+var _ ast.Node = (*WikiWordEscape)(nil)
+
+// Kind reports the kind of this node.
+func (e *WikiWordEscape) Kind() ast.NodeKind {
+	return WikiWordEscapeKind
+}
+
+// Dump dumps the WikiWordEscape to stdout.
+func (e *WikiWordEscape) Dump(src []byte, level int) {
+	ast.DumpHelper(e, src, level, nil, nil)
+}
+
+// renderChildren is a goldmark NodeRendererFunc
+// that renders only the children of a node.
+func renderChildren(util.BufWriter, []byte, ast.Node, bool) (ast.WalkStatus, error) {
+	return ast.WalkContinue, nil
+}
+
+// WikiWordParser parses CamelCase words like WikiWord as wikilinks,
+// the way older wikis like MoinMoin and TWiki link to pages.
+//
+//	See WikiWord for details.  // same as [[WikiWord]]
+//
+// A WikiWord is an ASCII word made of two or more capitalized parts,
+// each an uppercase letter followed by lowercase letters or digits.
+// For example, FooBar, WikiWord, and PageV2Notes are WikiWords,
+// but Foo, FOOBar, and fooBar are not.
+// WikiWords must start a line or follow a space,
+// an opening parenthesis, or a quote.
+// They may be wrapped in emphasis, as in *FooBar*.
+//
+// Prefix a WikiWord with "!" to prevent it from becoming a link.
+// The "!" is not rendered.
+// Escaped WikiWords are parsed as WikiWordEscape nodes.
+//
+//	!NotLinked
+//
+// WikiWords inside code spans, URLs, and the brackets of Markdown links
+// do not become links.
+// WikiWords are resolved and rendered like other wikilinks.
+//
+// Install it on your goldmark Markdown object with Extender's WikiWords
+// option, or install it directly on your goldmark Parser by using the
+// WithInlineParsers option.
+//
+//	wikiWordParser := util.Prioritized(&wikilink.WikiWordParser{}, 198)
+//	goldmarkParser.AddOptions(parser.WithInlineParsers(wikiWordParser))
+//
+// A WikiWordParser is safe for concurrent use by multiple goroutines.
+type WikiWordParser struct{}
+
+var _ parser.InlineParser = (*WikiWordParser)(nil)
+
+// goldmark also triggers parsers for ' ' at the start of each line.
+var _wikiWordTrigger = []byte{' ', '\t', '(', '"', '\'', '!'}
+
+// Trigger returns characters that trigger this parser.
+func (p *WikiWordParser) Trigger() []byte {
+	return _wikiWordTrigger
+}
+
+// Parse parses a WikiWord or an escaped WikiWord
+// along with the character that precedes it, if any.
+func (p *WikiWordParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	if pc.IsInLinkLabel() {
+		return nil
+	}
+
+	line, seg := block.PeekLine()
+	var lead int // size of the trigger character before the WikiWord
+	escaped := false
+	switch c := line[0]; {
+	case isUpper(c):
+		// goldmark calls this at the start of a line,
+		// but also after other inline nodes like "`code`FooBar".
+		if !atWikiWordStart(block) {
+			return nil
+		}
+	case c == '!':
+		if !isWordBoundary(block.PrecendingCharacter()) {
+			return nil
+		}
+		escaped = true
+		lead = 1
+	case isWikiWordLead(rune(c)):
+		lead = 1
+	default:
+		return nil
+	}
+
+	size := wikiWordLen(line[lead:])
+	if size == 0 || inURL(block.Source(), seg.Start+lead) {
+		return nil
+	}
+	wordSeg := text.NewSegment(seg.Start+lead, seg.Start+lead+size)
+	block.Advance(lead + size)
+
+	if escaped {
+		esc := &WikiWordEscape{Segment: text.NewSegment(seg.Start, wordSeg.Stop)}
+		esc.AppendChild(esc, ast.NewTextSegment(wordSeg))
+		return esc
+	}
+	if lead > 0 {
+		ast.MergeOrAppendTextSegment(parent, seg.WithStop(seg.Start+lead))
+	}
+
+	ln := &labeledNode{
+		node: Node{
+			Target:  block.Value(wordSeg),
+			Segment: wordSeg,
+			conv:    getConversion(pc),
+			bare:    true,
+		},
+	}
+	ln.label.Segment = wordSeg
+
+	n := &ln.node
+	n.AppendChild(n, &ln.label)
+	return n
+}
+
+// atWikiWordStart reports whether a WikiWord may start
+// at the current position of the reader:
+// at the start of a line or after a lead character,
+// optionally followed by emphasis delimiters as in "*FooBar*".
+func atWikiWordStart(block text.Reader) bool {
+	if block.LineOffset() == 0 {
+		return true
+	}
+
+	_, seg := block.Position()
+	src := block.Source()
+	i := seg.Start
+	for i > 0 && (src[i-1] == '*' || src[i-1] == '_') {
+		i--
+	}
+	return i == 0 || src[i-1] == '\n' || isWikiWordLead(rune(src[i-1]))
+}
+
+// isWikiWordLead reports whether a WikiWord may follow
+// the provided character.
+func isWikiWordLead(r rune) bool {
+	return r < 0x80 && (isSpace(byte(r)) || r == '(' || r == '"' || r == '\'')
+}
+
+// wikiWordLen returns the length of the WikiWord at the start of b,
+// or 0 if b doesn't start with one.
+func wikiWordLen(b []byte) int {
+	var i, parts int
+	for i < len(b) && isUpper(b[i]) {
+		start := i
+		i++
+		for i < len(b) && (isLower(b[i]) || isDigit(b[i])) {
+			i++
+		}
+		if i-start < 2 {
+			return 0 // FOOBar
+		}
+		parts++
+	}
+
+	if parts < 2 || (i < len(b) && (b[i] >= 0x80 || b[i] == '_')) {
+		return 0 // FooBar_baz
+	}
+	return i
+}
+
+// isWordBoundary reports whether a WikiWord may start
+// after the provided character.
+// Characters that commonly appear inside URLs, paths, and identifiers
+// are not boundaries.
+func isWordBoundary(r rune) bool {
+	switch {
+	case r >= 0x80:
+		return false // non-ASCII letters
+	case isUpper(byte(r)), isLower(byte(r)), isDigit(byte(r)):
+		return false
+	}
+	return bytes.IndexByte([]byte("_/.:@#=&?%~-+\\"), byte(r)) < 0
+}
+
+// inURL reports whether the word at the provided offset
+// is part of a URL like https://example.com/FooBar or www.FooBar.com.
+func inURL(src []byte, off int) bool {
+	start := off
+	for start > 0 && !isSpace(src[start-1]) {
+		start--
+	}
+	end := off
+	for end < len(src) && !isSpace(src[end]) {
+		end++
+	}
+
+	token := src[start:end]
+	return bytes.Contains(token, []byte("://")) || bytes.HasPrefix(token, []byte("www."))
+}
+
+func isUpper(c byte) bool { return 'A' <= c && c <= 'Z' }
+func isLower(c byte) bool { return 'a' <= c && c <= 'z' }
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
@@ -0,0 +1,255 @@
+package wikilink
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+func TestWikiWords(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give string
+		want string
+	}{
+		{
+			desc: "word",
+			give: "See WikiWord for details.",
+			want: `<p>See <a href="WikiWord.html">WikiWord</a> for details.</p>`,
+		},
+		{
+			desc: "start and end",
+			give: "FooBar",
+			want: `<p><a href="FooBar.html">FooBar</a></p>`,
+		},
+		{
+			desc: "digits",
+			give: "PageV2Notes and FooBar2",
+			want: `<p><a href="PageV2Notes.html">PageV2Notes</a> and <a href="FooBar2.html">FooBar2</a></p>`,
+		},
+		{
+			desc: "punctuation",
+			give: "(FooBar), *BazQux*!",
+			want: `<p>(<a href="FooBar.html">FooBar</a>), <em><a href="BazQux.html">BazQux</a></em>!</p>`,
+		},
+		{
+			desc: "after inline nodes",
+			give: "[[Foo]]BarBaz `code`FooBar *em*FooBar <b>FooBar</b>",
+			want: `<p><a href="Foo.html">Foo</a>BarBaz <code>code</code>FooBar <em>em</em>FooBar ` +
+				`<!-- raw HTML omitted -->FooBar<!-- raw HTML omitted --></p>`,
+		},
+		{
+			desc: "line starts",
+			give: "> FooBar\n> BarBaz\n>BazQux",
+			want: "<blockquote>\n<p><a href=\"FooBar.html\">FooBar</a>\n<a href=\"BarBaz.html\">BarBaz</a>\n" +
+				"<a href=\"BazQux.html\">BazQux</a></p>\n</blockquote>",
+		},
+		{
+			desc: "quotes",
+			give: `"FooBar" and 'BazQux'`,
+			want: `<p>&quot;<a href="FooBar.html">FooBar</a>&quot; and '<a href="BazQux.html">BazQux</a>'</p>`,
+		},
+		{
+			desc: "not wiki words",
+			give: "Foo FOOBar fooBar FooBarX FooBar_baz FooBarés iPhone",
+			want: `<p>Foo FOOBar fooBar FooBarX FooBar_baz FooBarés iPhone</p>`,
+		},
+		{
+			desc: "escaped",
+			give: "!NotLinked but IsLinked",
+			want: `<p>NotLinked but <a href="IsLinked.html">IsLinked</a></p>`,
+		},
+		{
+			desc: "bang",
+			give: "Wow! FooBar! ![img](foo.png)",
+			want: `<p>Wow! <a href="FooBar.html">FooBar</a>! <img src="foo.png" alt="img"></p>`,
+		},
+		{
+			desc: "code span",
+			give: "`FooBar` and ```BazQux```",
+			want: `<p><code>FooBar</code> and <code>BazQux</code></p>`,
+		},
+		{
+			desc: "code block",
+			give: "    FooBar",
+			want: "<pre><code>FooBar\n</code></pre>",
+		},
+		{
+			desc: "URL",
+			give: "https://example.com/FooBar and www.FooBar.com",
+			want: `<p>https://example.com/FooBar and www.FooBar.com</p>`,
+		},
+		{
+			desc: "autolink",
+			give: "<https://example.com/FooBar>",
+			want: `<p><a href="https://example.com/FooBar">https://example.com/FooBar</a></p>`,
+		},
+		{
+			desc: "path",
+			give: "docs/FooBar.md and foo.BarBaz",
+			want: `<p>docs/FooBar.md and foo.BarBaz</p>`,
+		},
+		{
+			desc: "markdown link",
+			give: "[about FooBar](https://example.com) and [FooBar]",
+			want: `<p><a href="https://example.com">about FooBar</a> and [FooBar]</p>`,
+		},
+		{
+			desc: "nested markdown link",
+			give: "[*FooBar*](https://example.com)",
+			want: `<p><a href="https://example.com"><em>FooBar</em></a></p>`,
+		},
+		{
+			desc: "image",
+			give: "![FooBar](foo.png)",
+			want: `<p><img src="foo.png" alt="FooBar"></p>`,
+		},
+		{
+			desc: "wikilink",
+			give: "[[FooBar|BazQux]]",
+			want: `<p><a href="FooBar.html">BazQux</a></p>`,
+		},
+		{
+			desc: "heading",
+			give: "# About FooBar",
+			want: `<h1>About <a href="FooBar.html">FooBar</a></h1>`,
+		},
+	}
+
+	md := goldmark.New(goldmark.WithExtensions(&Extender{WikiWords: true}))
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			require.NoError(t, md.Convert([]byte(tt.give), &buf))
+			assert.Equal(t, tt.want, strings.TrimSpace(buf.String()))
+		})
+	}
+}
+
+func TestWikiWords_disabled(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, goldmark.New(goldmark.WithExtensions(&Extender{})).
+		Convert([]byte("FooBar !BazQux"), &buf))
+	assert.Equal(t, "<p>FooBar !BazQux</p>", strings.TrimSpace(buf.String()))
+}
+
+func TestWikiWordParser(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("FooBar baz")
+	r := text.NewReader(src)
+
+	var p WikiWordParser
+	got := p.Parse(nil /* parent */, r, parser.NewContext())
+	n, ok := got.(*Node)
+	require.True(t, ok, "expected Node, got %T", got)
+
+	assert.Equal(t, "FooBar", string(n.Target))
+	assert.Empty(t, n.Fragment)
+	assert.False(t, n.Embed)
+	assert.Equal(t, "FooBar", string(n.Segment.Value(src)))
+	assert.Equal(t, "FooBar", renderMarkdown(t, src, n), "markdown")
+
+	_, pos := r.Position()
+	assert.Equal(t, " baz", string(r.Value(pos)), "remainder")
+}
+
+func TestWikiWordParser_escaped(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("!FooBar baz")
+	r := text.NewReader(src)
+
+	var p WikiWordParser
+	got := p.Parse(nil /* parent */, r, parser.NewContext())
+	esc, ok := got.(*WikiWordEscape)
+	require.True(t, ok, "expected WikiWordEscape, got %T", got)
+	assert.Equal(t, "!FooBar", string(esc.Segment.Value(src)))
+	assert.Equal(t, "FooBar", string(nodeText(src, esc)))
+
+	_, pos := r.Position()
+	assert.Equal(t, " baz", string(r.Value(pos)), "remainder")
+}
+
+func TestWikiWordEscape_render(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		renderer renderer.NodeRenderer
+		want     string
+	}{
+		{
+			desc:     "text",
+			renderer: &TextRenderer{},
+			want:     "<p>NotLinked but IsLinked</p>\n",
+		},
+		{
+			desc:     "markdown",
+			renderer: &MarkdownRenderer{},
+			want:     "<p>!NotLinked but IsLinked</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			// goldmark's HTML renderer renders the paragraph and text.
+			md := goldmark.New(
+				goldmark.WithParserOptions(parser.WithInlineParsers(
+					util.Prioritized(&WikiWordParser{}, 198),
+				)),
+				goldmark.WithRendererOptions(renderer.WithNodeRenderers(
+					util.Prioritized(tt.renderer, 199),
+				)),
+			)
+
+			var buf bytes.Buffer
+			require.NoError(t, md.Convert([]byte("!NotLinked but IsLinked"), &buf))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWikiWordLen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give string
+		want int
+	}{
+		{"FooBar", 6},
+		{"FooBar baz", 6},
+		{"FooBar.", 6},
+		{"FooBarBaz's", 9},
+		{"A1B2", 4},
+		{"Foo", 0},
+		{"FooB", 0},
+		{"FOOBar", 0},
+		{"fooBar", 0},
+		{"FooBar_", 0},
+		{"FooBaré", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, wikiWordLen([]byte(tt.give)), "wikiWordLen(%q)", tt.give)
+	}
+}